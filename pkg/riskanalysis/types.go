@@ -14,7 +14,14 @@ type ProwJobRun struct {
 	ProwJob     ProwJob
 	ClusterData platformidentification.ClusterData
 	Tests       []ProwJobRunTest
-	TestCount   int
+	// AllTests is only populated when requested and includes passing tests.
+	AllTests  []ProwJobRunTest `json:",omitempty"`
+	TestCount int
+	// Repo, PullNumber and BaseSHA identify the pull request tested by a presubmit job, they
+	// are empty for periodic jobs.
+	Repo       string `json:",omitempty"`
+	PullNumber int    `json:",omitempty"`
+	BaseSHA    string `json:",omitempty"`
 }
 
 type ProwJob struct {
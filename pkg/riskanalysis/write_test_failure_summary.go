@@ -3,21 +3,128 @@ package riskanalysis
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/openshift/origin/pkg/clioptions/clusterinfo"
+	"github.com/openshift/origin/pkg/monitortestlibrary/platformidentification"
 
 	"github.com/openshift/origin/pkg/test/ginkgo/junitapi"
 )
 
+// testFailurePerSuiteSummaryFilePrefix must not start with testFailureSummaryFilePrefix, so that
+// the risk analysis does not read the per-suite summaries along with the combined one.
+const testFailurePerSuiteSummaryFilePrefix = "test-failures-per-suite-summary"
+
+// SummaryOption configures optional content of the job run test failure summary.
+type SummaryOption func(*summaryOptions)
+
+type summaryOptions struct {
+	includeAllTests bool
+}
+
+// WithAllTests adds every test that ran, including the passing ones, to the AllTests
+// field of the summary. This grows the artifact considerably and is off by default.
+func WithAllTests() SummaryOption {
+	return func(o *summaryOptions) {
+		o.includeAllTests = true
+	}
+}
+
 // WriteJobRunTestFailureSummary writes a more minimal json file summarizing a little info about the
 // job run, and what tests flaked and failed. (successful tests are omitted)
 // This is intended to be later submitted to sippy for a risk analysis of how unusual the
 // test failures were, but that final step is handled elsewhere.
-func WriteJobRunTestFailureSummary(artifactDir, timeSuffix string, finalSuiteResults *junitapi.JUnitTestSuite, wasMasterNodeUpdated, outputFileSubStr string) error {
+func WriteJobRunTestFailureSummary(artifactDir, timeSuffix string, finalSuiteResults *junitapi.JUnitTestSuite, wasMasterNodeUpdated, outputFileSubStr string, opts ...SummaryOption) error {
+	restConfig, err := clusterinfo.GetMonitorRESTConfig()
+	if err != nil {
+		return err
+	}
+	jr := buildJobRunTestSummary(finalSuiteResults, clusterinfo.CollectClusterData(restConfig, wasMasterNodeUpdated), opts...)
+
+	jsonContent, err := json.MarshalIndent(jr, "", "    ")
+	if err != nil {
+		return err
+	}
+	outputFile := filepath.Join(artifactDir, fmt.Sprintf("%s%s%s.json",
+		testFailureSummaryFilePrefix, outputFileSubStr, timeSuffix))
+	return ioutil.WriteFile(outputFile, jsonContent, 0644)
+}
+
+// WriteJobRunTestFailureSummaryPerSuite writes one summary file per suite, named
+// <prefix>-<suiteName>-<timeSuffix>.json, for consumers that prefer per-suite granularity
+// over the combined summary. Suite names are sanitized for filesystem use. The prefix differs
+// from the one of the combined summary so that the risk analysis does not count failures twice.
+func WriteJobRunTestFailureSummaryPerSuite(artifactDir, timeSuffix string, suites []*junitapi.JUnitTestSuite) error {
+	restConfig, err := clusterinfo.GetMonitorRESTConfig()
+	if err != nil {
+		return err
+	}
+	return writePerSuiteSummaries(artifactDir, timeSuffix, suites, clusterinfo.CollectClusterData(restConfig, ""))
+}
+
+func writePerSuiteSummaries(artifactDir, timeSuffix string, suites []*junitapi.JUnitTestSuite, clusterData platformidentification.ClusterData) error {
+	written := map[string]string{}
+	for _, suite := range suites {
+		fileName := fmt.Sprintf("%s-%s-%s.json", testFailurePerSuiteSummaryFilePrefix, sanitizeSuiteName(suite.Name), timeSuffix)
+		if other, ok := written[fileName]; ok {
+			return fmt.Errorf("suites %q and %q would both be written to %s", other, suite.Name, fileName)
+		}
+		written[fileName] = suite.Name
+
+		jsonContent, err := json.MarshalIndent(buildJobRunTestSummary(suite, clusterData), "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(artifactDir, fileName), jsonContent, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJobRunTestFailuresJSONL writes the failed tests of the suite to w as JSON lines, one
+// ProwJobRunTest per line sorted by test name, for log aggregators and runs too large to handle
+// as a single document. Tests are classified as in WriteJobRunTestFailureSummary.
+func WriteJobRunTestFailuresJSONL(w io.Writer, suite *junitapi.JUnitTestSuite) error {
+	tests := buildJobRunTestSummary(suite, platformidentification.ClusterData{}).Tests
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Test.Name < tests[j].Test.Name })
+	encoder := json.NewEncoder(w)
+	for _, test := range tests {
+		if err := encoder.Encode(test); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// unnamedSuiteFileName stands for suites whose name has no character safe in a file name.
+const unnamedSuiteFileName = "unnamed-suite"
+
+// sanitizeSuiteName replaces the characters of a suite name that are not safe in a file
+// name, such as slashes and spaces, with dashes.
+func sanitizeSuiteName(name string) string {
+	sanitized := strings.Trim(unsafeFileNameChars.ReplaceAllString(name, "-"), "-.")
+	if len(sanitized) == 0 {
+		return unnamedSuiteFileName
+	}
+	return sanitized
+}
+
+// buildJobRunTestSummary summarizes the suite results into the ProwJobRun submitted to sippy.
+func buildJobRunTestSummary(finalSuiteResults *junitapi.JUnitTestSuite, clusterData platformidentification.ClusterData, opts ...SummaryOption) ProwJobRun {
+	options := &summaryOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
 
 	tests := map[string]*passFail{}
 
@@ -39,19 +146,23 @@ func WriteJobRunTestFailureSummary(artifactDir, timeSuffix string, finalSuiteRes
 	// If we can't parse this, we submit without it, it is not required.
 	jobRunID, _ := strconv.Atoi(os.Getenv("BUILD_ID"))
 
-	restConfig, err := clusterinfo.GetMonitorRESTConfig()
-	if err != nil {
-		return err
-	}
 	jr := ProwJobRun{
 		ID:          jobRunID,
 		ProwJob:     ProwJob{Name: os.Getenv("JOB_NAME")},
-		ClusterData: clusterinfo.CollectClusterData(restConfig, wasMasterNodeUpdated),
+		ClusterData: clusterData,
 		Tests:       []ProwJobRunTest{},
 		TestCount:   len(tests),
 	}
+	setPullRequestMetadata(&jr)
 
 	for k, v := range tests {
+		if options.includeAllTests && (v.Passed || v.Failed) {
+			jr.AllTests = append(jr.AllTests, ProwJobRunTest{
+				Test:   Test{Name: k},
+				Suite:  Suite{Name: finalSuiteResults.Name},
+				Status: getSippyStatusCode(v),
+			})
+		}
 		if !v.Failed {
 			// if no failures, it is neither a fail nor a flake:
 			continue
@@ -67,13 +178,19 @@ func WriteJobRunTestFailureSummary(artifactDir, timeSuffix string, finalSuiteRes
 		})
 	}
 
-	jsonContent, err := json.MarshalIndent(jr, "", "    ")
-	if err != nil {
-		return err
+	return jr
+}
+
+// setPullRequestMetadata records the pull request tested by a presubmit job, from the environment
+// prow sets for it. Periodic jobs do not set these variables and are left untouched.
+func setPullRequestMetadata(jr *ProwJobRun) {
+	owner, name := os.Getenv("REPO_OWNER"), os.Getenv("REPO_NAME")
+	if len(owner) > 0 && len(name) > 0 {
+		jr.Repo = owner + "/" + name
 	}
-	outputFile := filepath.Join(artifactDir, fmt.Sprintf("%s%s%s.json",
-		testFailureSummaryFilePrefix, outputFileSubStr, timeSuffix))
-	return ioutil.WriteFile(outputFile, jsonContent, 0644)
+	// If we can't parse this, we submit without it, it is not required.
+	jr.PullNumber, _ = strconv.Atoi(os.Getenv("PULL_NUMBER"))
+	jr.BaseSHA = os.Getenv("PULL_BASE_SHA")
 }
 
 // passFail is a simple struct to track test names which can appear more than once.
@@ -90,6 +207,8 @@ func getSippyStatusCode(pf *passFail) int {
 		return 13 // flake
 	case pf.Failed && !pf.Passed:
 		return 12 // fail
+	case pf.Passed:
+		return 1 // pass
 	}
 	// we should not hit this given the above filtering
 	return 0
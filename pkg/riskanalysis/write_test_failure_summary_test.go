@@ -0,0 +1,183 @@
+package riskanalysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/origin/pkg/monitortestlibrary/platformidentification"
+	"github.com/openshift/origin/pkg/test/ginkgo/junitapi"
+)
+
+func TestBuildJobRunTestSummary(t *testing.T) {
+	suite := &junitapi.JUnitTestSuite{
+		Name: "openshift-tests",
+		TestCases: []*junitapi.JUnitTestCase{
+			{Name: "passing"},
+			{Name: "failing", FailureOutput: &junitapi.FailureOutput{Output: "boom"}},
+			{Name: "flaking", FailureOutput: &junitapi.FailureOutput{Output: "boom"}},
+			{Name: "flaking"},
+			{Name: "skipped", SkipMessage: &junitapi.SkipMessage{Message: "skip"}},
+		},
+	}
+	statuses := func(tests []ProwJobRunTest) map[string]int {
+		result := map[string]int{}
+		for _, test := range tests {
+			result[test.Test.Name] = test.Status
+		}
+		return result
+	}
+
+	jr := buildJobRunTestSummary(suite, platformidentification.ClusterData{})
+	assert.Equal(t, map[string]int{"failing": 12}, statuses(jr.Tests))
+	assert.Empty(t, jr.AllTests, "passing tests should be omitted by default")
+	assert.Equal(t, 4, jr.TestCount)
+
+	jr = buildJobRunTestSummary(suite, platformidentification.ClusterData{}, WithAllTests())
+	assert.Equal(t, map[string]int{"failing": 12}, statuses(jr.Tests))
+	assert.Equal(t, map[string]int{"passing": 1, "failing": 12, "flaking": 13}, statuses(jr.AllTests))
+	for _, test := range jr.AllTests {
+		assert.Equal(t, "openshift-tests", test.Suite.Name)
+	}
+}
+
+func TestWriteJobRunTestFailuresJSONL(t *testing.T) {
+	suite := &junitapi.JUnitTestSuite{
+		Name: "openshift-tests",
+		TestCases: []*junitapi.JUnitTestCase{
+			{Name: "passing"},
+			{Name: "failing \"quoted\"\nmultiline", FailureOutput: &junitapi.FailureOutput{Output: "boom"}},
+			{Name: "another failing", FailureOutput: &junitapi.FailureOutput{Output: "boom"}},
+			{Name: "flaking", FailureOutput: &junitapi.FailureOutput{Output: "boom"}},
+			{Name: "flaking"},
+		},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, WriteJobRunTestFailuresJSONL(&out, suite))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	var names []string
+	for _, line := range lines {
+		var test ProwJobRunTest
+		if assert.NoError(t, json.Unmarshal([]byte(line), &test), "line %q should be valid JSON on its own", line) {
+			assert.Equal(t, "openshift-tests", test.Suite.Name)
+			assert.Equal(t, 12, test.Status)
+			names = append(names, test.Test.Name)
+		}
+	}
+	assert.Equal(t, []string{"another failing", "failing \"quoted\"\nmultiline"}, names)
+
+	out.Reset()
+	assert.NoError(t, WriteJobRunTestFailuresJSONL(&out, &junitapi.JUnitTestSuite{Name: "empty"}))
+	assert.Empty(t, out.String())
+}
+
+func TestBuildJobRunTestSummaryPullRequestMetadata(t *testing.T) {
+	suite := &junitapi.JUnitTestSuite{Name: "openshift-tests"}
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected ProwJobRun
+		json     string
+	}{
+		{
+			name: "presubmit",
+			env: map[string]string{
+				"REPO_OWNER":    "openshift",
+				"REPO_NAME":     "origin",
+				"PULL_NUMBER":   "28000",
+				"PULL_BASE_SHA": "0123456789abcdef",
+			},
+			expected: ProwJobRun{Repo: "openshift/origin", PullNumber: 28000, BaseSHA: "0123456789abcdef"},
+			json:     `"Repo":"openshift/origin","PullNumber":28000,"BaseSHA":"0123456789abcdef"`,
+		},
+		{
+			name: "periodic",
+			env: map[string]string{
+				"REPO_OWNER":    "",
+				"REPO_NAME":     "",
+				"PULL_NUMBER":   "",
+				"PULL_BASE_SHA": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			jr := buildJobRunTestSummary(suite, platformidentification.ClusterData{})
+			assert.Equal(t, tt.expected.Repo, jr.Repo)
+			assert.Equal(t, tt.expected.PullNumber, jr.PullNumber)
+			assert.Equal(t, tt.expected.BaseSHA, jr.BaseSHA)
+
+			content, err := json.Marshal(jr)
+			assert.NoError(t, err)
+			if len(tt.json) > 0 {
+				assert.Contains(t, string(content), tt.json)
+			} else {
+				for _, field := range []string{"Repo", "PullNumber", "BaseSHA"} {
+					assert.NotContains(t, string(content), field)
+				}
+			}
+		})
+	}
+}
+
+func TestWritePerSuiteSummaries(t *testing.T) {
+	dir := t.TempDir()
+	suites := []*junitapi.JUnitTestSuite{
+		{
+			Name:      "openshift/conformance parallel",
+			TestCases: []*junitapi.JUnitTestCase{{Name: "failing", FailureOutput: &junitapi.FailureOutput{Output: "boom"}}},
+		},
+		{
+			Name:      "openshift-tests-upgrade",
+			TestCases: []*junitapi.JUnitTestCase{{Name: "passing"}},
+		},
+	}
+
+	err := writePerSuiteSummaries(dir, "20240102-030405", suites, platformidentification.ClusterData{})
+	assert.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"test-failures-per-suite-summary-openshift-conformance-parallel-20240102-030405.json",
+		"test-failures-per-suite-summary-openshift-tests-upgrade-20240102-030405.json",
+	}, names)
+
+	content, err := os.ReadFile(filepath.Join(dir, "test-failures-per-suite-summary-openshift-conformance-parallel-20240102-030405.json"))
+	assert.NoError(t, err)
+	jr := ProwJobRun{}
+	assert.NoError(t, json.Unmarshal(content, &jr))
+	assert.Len(t, jr.Tests, 1)
+	assert.Equal(t, "openshift/conformance parallel", jr.Tests[0].Suite.Name)
+}
+
+func TestWritePerSuiteSummariesNames(t *testing.T) {
+	dir := t.TempDir()
+	err := writePerSuiteSummaries(dir, "20240102-030405", []*junitapi.JUnitTestSuite{{Name: "//"}}, platformidentification.ClusterData{})
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "test-failures-per-suite-summary-unnamed-suite-20240102-030405.json"))
+	assert.NoError(t, err)
+
+	matches, err := filepath.Glob(filepath.Join(dir, testFailureSummaryFilePrefix+"*.json"))
+	assert.NoError(t, err)
+	assert.Empty(t, matches, "per-suite summaries must not be read as combined summaries")
+
+	err = writePerSuiteSummaries(t.TempDir(), "20240102-030405", []*junitapi.JUnitTestSuite{
+		{Name: "openshift/conformance"},
+		{Name: "openshift conformance"},
+	}, platformidentification.ClusterData{})
+	assert.ErrorContains(t, err, "would both be written")
+}
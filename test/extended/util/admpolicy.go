@@ -0,0 +1,174 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// admPolicyVerifyTimeout bounds how long the AdmPolicy wrappers wait for a change to be effective.
+const admPolicyVerifyTimeout = 2 * time.Minute
+
+// admPolicyGroupVerifier is the user impersonated, along with the group, to verify the roles
+// bound to a group.
+const admPolicyGroupVerifier = "system:e2e-adm-policy-verifier"
+
+// AdmPolicyOption configures the AdmPolicy wrappers.
+type AdmPolicyOption func(*admPolicyOptions)
+
+type admPolicyOptions struct {
+	revertAtTeardown bool
+}
+
+// RevertAtTeardown reverts the change when the current spec ends.
+func RevertAtTeardown() AdmPolicyOption {
+	return func(o *admPolicyOptions) {
+		o.revertAtTeardown = true
+	}
+}
+
+// AdmPolicyAddRoleToUser runs `oc adm policy add-role-to-user` to bind the cluster role to the user
+// in the namespace, then waits until a SelfSubjectAccessReview made as the user, through
+// impersonation, allows the first rule of the role.
+func (c *CLI) AdmPolicyAddRoleToUser(role, user, namespace string, opts ...AdmPolicyOption) error {
+	subject := rest.ImpersonationConfig{UserName: user}
+	return c.admPolicy([]string{"add-role-to-user", role, user, "-n", namespace}, []string{"remove-role-from-user", role, user, "-n", namespace},
+		role, namespace, subject, true, opts)
+}
+
+// AdmPolicyRemoveRoleFromUser runs `oc adm policy remove-role-from-user` to remove the bindings of
+// the cluster role to the user in the namespace, then waits until a SelfSubjectAccessReview made as
+// the user denies the first rule of the role. The verification fails if the user is granted that
+// rule by another binding.
+func (c *CLI) AdmPolicyRemoveRoleFromUser(role, user, namespace string, opts ...AdmPolicyOption) error {
+	subject := rest.ImpersonationConfig{UserName: user}
+	return c.admPolicy([]string{"remove-role-from-user", role, user, "-n", namespace}, []string{"add-role-to-user", role, user, "-n", namespace},
+		role, namespace, subject, false, opts)
+}
+
+// AdmPolicyAddClusterRoleToGroup runs `oc adm policy add-cluster-role-to-group` to bind the
+// cluster role to the group cluster-wide, then waits until a SelfSubjectAccessReview made as a
+// member of the group allows the first rule of the role.
+func (c *CLI) AdmPolicyAddClusterRoleToGroup(clusterRole, group string, opts ...AdmPolicyOption) error {
+	subject := rest.ImpersonationConfig{UserName: admPolicyGroupVerifier, Groups: []string{group}}
+	return c.admPolicy([]string{"add-cluster-role-to-group", clusterRole, group}, []string{"remove-cluster-role-from-group", clusterRole, group},
+		clusterRole, "", subject, true, opts)
+}
+
+// admPolicy runs the oc adm policy command, registers its reversal if requested and verifies that
+// the first rule of the cluster role is allowed, or denied, to the subject in the namespace.
+func (c *CLI) admPolicy(args, revertArgs []string, role, namespace string, subject rest.ImpersonationConfig, allowed bool, opts []AdmPolicyOption) error {
+	options := &admPolicyOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	clusterRole, err := c.AdminKubeClient().RbacV1().ClusterRoles().Get(context.Background(), role, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get cluster role %s: %w", role, err)
+	}
+	spec, err := accessReviewForRole(clusterRole, namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := c.AsAdmin().WithoutNamespace().Run("adm").Args(append([]string{"policy"}, args...)...).Execute(); err != nil {
+		return err
+	}
+	if options.revertAtTeardown {
+		g.DeferCleanup(func() {
+			err := c.AsAdmin().WithoutNamespace().Run("adm").Args(append([]string{"policy"}, revertArgs...)...).Execute()
+			e2e.Logf("Reverted oc adm policy %v, err: %v", args, err)
+		})
+	}
+
+	config := rest.CopyConfig(c.AdminConfig())
+	config.Impersonate = subject
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	var last *authorizationv1.SelfSubjectAccessReview
+	err = pollUntilTimeout(context.Background(), time.Second, admPolicyVerifyTimeout, true, func(ctx context.Context) (bool, error) {
+		review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{Spec: *spec}, metav1.CreateOptions{})
+		if err != nil {
+			e2e.Logf("Unable to review the access of %s: %v", describeImpersonation(subject), err)
+			return false, nil
+		}
+		last = review
+		return review.Status.Allowed == allowed, nil
+	})
+	if err != nil {
+		status := "no review succeeded"
+		if last != nil {
+			status = fmt.Sprintf("allowed=%t reason=%q evaluationError=%q", last.Status.Allowed, last.Status.Reason, last.Status.EvaluationError)
+		}
+		return fmt.Errorf("oc adm policy %v is not effective: %s expected allowed=%t for %s, got %s: %w",
+			args, describeImpersonation(subject), allowed, describeAccessReview(spec), status, err)
+	}
+	e2e.Logf("oc adm policy %v is effective for %s", args, describeImpersonation(subject))
+	return nil
+}
+
+// accessReviewForRole returns a review of the first rule of the role that can be granted in the
+// namespace, the first resource rule when namespace is set and the first rule otherwise.
+func accessReviewForRole(role *rbacv1.ClusterRole, namespace string) (*authorizationv1.SelfSubjectAccessReviewSpec, error) {
+	for _, rule := range role.Rules {
+		if len(rule.Verbs) == 0 {
+			continue
+		}
+		if len(rule.Resources) > 0 && len(rule.APIGroups) > 0 {
+			attributes := &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      rule.Verbs[0],
+				Group:     rule.APIGroups[0],
+				Resource:  rule.Resources[0],
+			}
+			if len(rule.ResourceNames) > 0 {
+				attributes.Name = rule.ResourceNames[0]
+			}
+			return &authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes}, nil
+		}
+		if len(rule.NonResourceURLs) > 0 && len(namespace) == 0 {
+			return &authorizationv1.SelfSubjectAccessReviewSpec{NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: rule.NonResourceURLs[0],
+				Verb: rule.Verbs[0],
+			}}, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster role %s has no rule that can be verified", role.Name)
+}
+
+func describeAccessReview(spec *authorizationv1.SelfSubjectAccessReviewSpec) string {
+	if attributes := spec.NonResourceAttributes; attributes != nil {
+		return fmt.Sprintf("%s %s", attributes.Verb, attributes.Path)
+	}
+	attributes := spec.ResourceAttributes
+	description := fmt.Sprintf("%s %s", attributes.Verb, attributes.Resource)
+	if len(attributes.Group) > 0 {
+		description += "." + attributes.Group
+	}
+	if len(attributes.Name) > 0 {
+		description += "/" + attributes.Name
+	}
+	if len(attributes.Namespace) > 0 {
+		return description + " in namespace " + attributes.Namespace
+	}
+	return description + " cluster-wide"
+}
+
+func describeImpersonation(subject rest.ImpersonationConfig) string {
+	if len(subject.Groups) > 0 {
+		return fmt.Sprintf("a member of group %v", subject.Groups)
+	}
+	return "user " + subject.UserName
+}
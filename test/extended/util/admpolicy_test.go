@@ -0,0 +1,40 @@
+package util
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAccessReviewForRole(t *testing.T) {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Rules: []rbacv1.PolicyRule{
+			{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets"}, ResourceNames: []string{"web"}, Verbs: []string{"update", "patch"}},
+		},
+	}
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{namespace: "e2e-test", want: "update deployments.apps/web in namespace e2e-test"},
+		{namespace: "", want: "get /healthz"},
+	}
+	for _, tt := range tests {
+		spec, err := accessReviewForRole(role, tt.namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := describeAccessReview(spec); got != tt.want {
+			t.Errorf("namespace %q: got %q, want %q", tt.namespace, got, tt.want)
+		}
+	}
+
+	// non-resource rules cannot be granted in a namespace
+	role.Rules = role.Rules[:1]
+	if _, err := accessReviewForRole(role, "e2e-test"); err == nil {
+		t.Errorf("expected an error for a role without namespaced rules")
+	}
+}
@@ -0,0 +1,143 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	o "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned"
+)
+
+const (
+	// encryptedConditionType is reported by the encryption controllers of the API server operators.
+	encryptedConditionType = "Encrypted"
+
+	encryptionCompletedReason = "EncryptionCompleted"
+	encryptionDisabledReason  = "EncryptionDisabled"
+)
+
+// getAPIServerConfig returns the cluster APIServer config object. It is not cached since
+// tests change the encryption and audit settings.
+func getAPIServerConfig(configClient configv1client.Interface) (*configv1.APIServer, error) {
+	apiServer, err := configClient.ConfigV1().APIServers().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failure getting test cluster APIServer: %w", err)
+	}
+	return apiServer, nil
+}
+
+// APIServerEncryptionType returns the etcd encryption type configured for the API servers.
+// An unset type is reported as identity, i.e. no encryption.
+func APIServerEncryptionType(oc *CLI) (configv1.EncryptionType, error) {
+	apiServer, err := getAPIServerConfig(oc.AdminConfigClient())
+	if err != nil {
+		return "", err
+	}
+	return encryptionTypeFromConfig(apiServer), nil
+}
+
+// AuditProfile returns the top-level audit profile configured for the API servers.
+// An unset profile is reported as Default.
+func AuditProfile(oc *CLI) (configv1.AuditProfileType, error) {
+	apiServer, err := getAPIServerConfig(oc.AdminConfigClient())
+	if err != nil {
+		return "", err
+	}
+	return auditProfileFromConfig(apiServer), nil
+}
+
+// APIServerAuditProfile returns the top-level audit profile configured for the API servers, see
+// AuditProfile. The config is read on every call, so a profile changed by the test is reported.
+// Tests gate on it with SkipUnlessAuditProfile.
+func (c *CLI) APIServerAuditProfile() (configv1.AuditProfileType, error) {
+	return AuditProfile(c)
+}
+
+// SkipUnlessEncryptionType skips the test unless etcd encryption uses one of the types.
+func SkipUnlessEncryptionType(oc *CLI, types ...configv1.EncryptionType) {
+	encryptionType, err := APIServerEncryptionType(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the etcd encryption type")
+	for _, t := range types {
+		if t == encryptionType {
+			return
+		}
+	}
+	skipper.Skipf("Etcd encryption type %q is not one of %v", encryptionType, types)
+}
+
+// SkipUnlessAuditProfile skips the test unless the API servers use one of the audit profiles.
+func SkipUnlessAuditProfile(oc *CLI, profiles ...configv1.AuditProfileType) {
+	profile, err := AuditProfile(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the audit profile")
+	for _, p := range profiles {
+		if p == profile {
+			return
+		}
+	}
+	skipper.Skipf("Audit profile %q is not one of %v", profile, profiles)
+}
+
+// WaitForEncryptionMigrated waits until the kube-apiserver operator reports that the
+// resources are migrated to the encryption type currently set in the APIServer config.
+func WaitForEncryptionMigrated(oc *CLI, timeout time.Duration) error {
+	encryptionType, err := APIServerEncryptionType(oc)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var lastStatus string
+	err = pollUntilTimeout(ctx, 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		kas, err := oc.AdminOperatorClient().OperatorV1().KubeAPIServers().Get(ctx, "cluster", metav1.GetOptions{})
+		if err != nil {
+			lastStatus = err.Error()
+			return false, nil
+		}
+		var migrated bool
+		migrated, lastStatus = encryptionMigrated(kas.Status.Conditions, encryptionType)
+		return migrated, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for the encryption migration to %s: %w (last status: %s)", encryptionType, err, lastStatus)
+	}
+	e2e.Logf("Encryption migration to %s completed", encryptionType)
+	return nil
+}
+
+func encryptionTypeFromConfig(apiServer *configv1.APIServer) configv1.EncryptionType {
+	if len(apiServer.Spec.Encryption.Type) == 0 {
+		return configv1.EncryptionTypeIdentity
+	}
+	return apiServer.Spec.Encryption.Type
+}
+
+func auditProfileFromConfig(apiServer *configv1.APIServer) configv1.AuditProfileType {
+	if len(apiServer.Spec.Audit.Profile) == 0 {
+		return configv1.DefaultAuditProfileType
+	}
+	return apiServer.Spec.Audit.Profile
+}
+
+// encryptionMigrated returns whether the Encrypted condition reports the migration to the
+// encryption type as done, along with a description of the condition.
+func encryptionMigrated(conditions []operatorv1.OperatorCondition, encryptionType configv1.EncryptionType) (bool, string) {
+	for _, condition := range conditions {
+		if condition.Type != encryptedConditionType {
+			continue
+		}
+		status := fmt.Sprintf("%s=%s (%s): %s", condition.Type, condition.Status, condition.Reason, condition.Message)
+		if encryptionType == configv1.EncryptionTypeIdentity {
+			return condition.Status == operatorv1.ConditionFalse && condition.Reason == encryptionDisabledReason, status
+		}
+		return condition.Status == operatorv1.ConditionTrue && condition.Reason == encryptionCompletedReason, status
+	}
+	return false, fmt.Sprintf("no %s condition", encryptedConditionType)
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	fakeconfigv1client "github.com/openshift/client-go/config/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAPIServerConfig(t *testing.T) {
+	tests := []struct {
+		name               string
+		spec               configv1.APIServerSpec
+		expectedEncryption configv1.EncryptionType
+		expectedProfile    configv1.AuditProfileType
+	}{
+		{
+			name:               "defaults",
+			expectedEncryption: configv1.EncryptionTypeIdentity,
+			expectedProfile:    configv1.DefaultAuditProfileType,
+		},
+		{
+			name: "aescbc with request bodies",
+			spec: configv1.APIServerSpec{
+				Encryption: configv1.APIServerEncryption{Type: configv1.EncryptionTypeAESCBC},
+				Audit:      configv1.Audit{Profile: configv1.WriteRequestBodiesAuditProfileType},
+			},
+			expectedEncryption: configv1.EncryptionTypeAESCBC,
+			expectedProfile:    configv1.WriteRequestBodiesAuditProfileType,
+		},
+		{
+			name: "aesgcm without audit",
+			spec: configv1.APIServerSpec{
+				Encryption: configv1.APIServerEncryption{Type: configv1.EncryptionTypeAESGCM},
+				Audit:      configv1.Audit{Profile: configv1.NoneAuditProfileType},
+			},
+			expectedEncryption: configv1.EncryptionTypeAESGCM,
+			expectedProfile:    configv1.NoneAuditProfileType,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fakeconfigv1client.NewSimpleClientset(&configv1.APIServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       test.spec,
+			})
+			apiServer, err := getAPIServerConfig(client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := encryptionTypeFromConfig(apiServer); actual != test.expectedEncryption {
+				t.Errorf("expected encryption type %q, got %q", test.expectedEncryption, actual)
+			}
+			if actual := auditProfileFromConfig(apiServer); actual != test.expectedProfile {
+				t.Errorf("expected audit profile %q, got %q", test.expectedProfile, actual)
+			}
+
+		})
+	}
+}
+
+func TestAPIServerConfigNotFound(t *testing.T) {
+	if _, err := getAPIServerConfig(fakeconfigv1client.NewSimpleClientset()); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestAPIServerConfigReadsChanges(t *testing.T) {
+	client := fakeconfigv1client.NewSimpleClientset(&configv1.APIServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+	})
+	apiServer, err := getAPIServerConfig(client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := encryptionTypeFromConfig(apiServer); actual != configv1.EncryptionTypeIdentity {
+		t.Fatalf("expected encryption type %q, got %q", configv1.EncryptionTypeIdentity, actual)
+	}
+
+	apiServer.Spec.Encryption.Type = configv1.EncryptionTypeAESGCM
+	if _, err := client.ConfigV1().APIServers().Update(context.Background(), apiServer, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	apiServer, err = getAPIServerConfig(client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := encryptionTypeFromConfig(apiServer); actual != configv1.EncryptionTypeAESGCM {
+		t.Errorf("expected encryption type %q after the change, got %q", configv1.EncryptionTypeAESGCM, actual)
+	}
+}
+
+func TestEncryptionMigrated(t *testing.T) {
+	encrypted := func(status operatorv1.ConditionStatus, reason string) []operatorv1.OperatorCondition {
+		return []operatorv1.OperatorCondition{
+			{Type: "Available", Status: operatorv1.ConditionTrue},
+			{Type: "Encrypted", Status: status, Reason: reason},
+		}
+	}
+	tests := []struct {
+		name           string
+		conditions     []operatorv1.OperatorCondition
+		encryptionType configv1.EncryptionType
+		expected       bool
+	}{
+		{
+			name:           "encryption completed",
+			conditions:     encrypted(operatorv1.ConditionTrue, "EncryptionCompleted"),
+			encryptionType: configv1.EncryptionTypeAESCBC,
+			expected:       true,
+		},
+		{
+			name:           "encryption in progress",
+			conditions:     encrypted(operatorv1.ConditionFalse, "EncryptionInProgress"),
+			encryptionType: configv1.EncryptionTypeAESCBC,
+		},
+		{
+			name:           "decryption completed",
+			conditions:     encrypted(operatorv1.ConditionFalse, "EncryptionDisabled"),
+			encryptionType: configv1.EncryptionTypeIdentity,
+			expected:       true,
+		},
+		{
+			name:           "still encrypted while turning encryption off",
+			conditions:     encrypted(operatorv1.ConditionTrue, "EncryptionCompleted"),
+			encryptionType: configv1.EncryptionTypeIdentity,
+		},
+		{
+			name:           "no condition",
+			conditions:     []operatorv1.OperatorCondition{{Type: "Available", Status: operatorv1.ConditionTrue}},
+			encryptionType: configv1.EncryptionTypeAESGCM,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, status := encryptionMigrated(test.conditions, test.encryptionType)
+			if actual != test.expected {
+				t.Errorf("expected %v, got %v (%s)", test.expected, actual, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	o "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+// NodeArchitectures returns the set of CPU architectures of the cluster nodes, as
+// reported by the kubernetes.io/arch label.
+func NodeArchitectures(oc *CLI) (sets.String, error) {
+	return getNodeArchitectures(oc.AdminKubeClient())
+}
+
+func getNodeArchitectures(kubeClient kubernetes.Interface) (sets.String, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	architectures := sets.NewString()
+	for _, node := range nodes.Items {
+		architectures.Insert(nodeArchitecture(&node))
+	}
+	return architectures, nil
+}
+
+// nodeArchitecture prefers the kubernetes.io/arch label and falls back to the node status.
+func nodeArchitecture(node *corev1.Node) string {
+	if arch, ok := node.Labels[corev1.LabelArchStable]; ok {
+		return arch
+	}
+	return node.Status.NodeInfo.Architecture
+}
+
+// IsMultiArchCluster returns true if the cluster nodes have more than one CPU architecture.
+func IsMultiArchCluster(oc *CLI) bool {
+	architectures, err := NodeArchitectures(oc)
+	if err != nil {
+		e2e.Logf("unable to determine the node architectures: %v", err)
+		return false
+	}
+	return architectures.Len() > 1
+}
+
+// SkipUnlessNodeArch skips the test unless at least one node has the given CPU architecture.
+func SkipUnlessNodeArch(oc *CLI, arch string) {
+	architectures, err := NodeArchitectures(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the node architectures")
+	if !architectures.Has(arch) {
+		skipper.Skipf("No node with architecture %q, found %v", arch, architectures.List())
+	}
+}
+
+// ImageForArch resolves baseRef and, if it is a manifest list, returns the digest
+// reference of the manifest for arch. References to single manifests are returned
+// unchanged. The reference is resolved by a dry-run ImageStreamImport in the
+// current namespace.
+func (c *CLI) ImageForArch(baseRef, arch string) (string, error) {
+	ref, err := reference.Parse(baseRef)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse image reference %q: %w", baseRef, err)
+	}
+
+	isi, err := c.ImageClient().ImageV1().ImageStreamImports(c.Namespace()).Create(context.Background(), &imagev1.ImageStreamImport{
+		ObjectMeta: metav1.ObjectMeta{Name: "image-for-arch"},
+		Spec: imagev1.ImageStreamImportSpec{
+			Import: false,
+			Images: []imagev1.ImageImportSpec{
+				{
+					From:         corev1.ObjectReference{Kind: "DockerImage", Name: baseRef},
+					ImportPolicy: imagev1.TagImportPolicy{ImportMode: imagev1.ImportModePreserveOriginal},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	if len(isi.Status.Images) == 0 {
+		return "", fmt.Errorf("no import status returned for %q", baseRef)
+	}
+	status := isi.Status.Images[0]
+	if status.Status.Status != metav1.StatusSuccess {
+		return "", fmt.Errorf("unable to import %q: %s", baseRef, status.Status.Message)
+	}
+	if status.Image == nil {
+		return "", fmt.Errorf("no image returned for %q", baseRef)
+	}
+	return imageForArchFromManifests(ref, status.Image.DockerImageManifests, arch)
+}
+
+func imageForArchFromManifests(ref reference.DockerImageReference, manifests []imagev1.ImageManifest, arch string) (string, error) {
+	if len(manifests) == 0 {
+		return ref.Exact(), nil
+	}
+	for _, manifest := range manifests {
+		if manifest.Architecture == arch {
+			ref = ref.AsRepository()
+			ref.ID = manifest.Digest
+			return ref.Exact(), nil
+		}
+	}
+	return "", fmt.Errorf("image %q has no manifest for architecture %q", ref.Exact(), arch)
+}
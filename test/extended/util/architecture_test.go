@@ -0,0 +1,148 @@
+package util
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ksets "k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+func TestNodeArchitectures(t *testing.T) {
+	tests := []struct {
+		name          string
+		nodes         []runtime.Object
+		expected      []string
+		expectedMulti bool
+	}{
+		{
+			name: "homogeneous",
+			nodes: []runtime.Object{
+				withArch(testNode("master-0", "master"), "amd64"),
+				withArch(testNode("worker-0", "worker"), "amd64"),
+			},
+			expected: []string{"amd64"},
+		},
+		{
+			name: "arm64 control plane with amd64 workers",
+			nodes: []runtime.Object{
+				withArch(testNode("master-0", "master"), "arm64"),
+				withArch(testNode("master-1", "master"), "arm64"),
+				withArch(testNode("worker-0", "worker"), "amd64"),
+			},
+			expected:      []string{"amd64", "arm64"},
+			expectedMulti: true,
+		},
+		{
+			name: "heterogeneous workers",
+			nodes: []runtime.Object{
+				withArch(testNode("master-0", "master"), "amd64"),
+				withArch(testNode("worker-0", "worker"), "ppc64le"),
+				withArch(testNode("worker-1", "worker"), "s390x"),
+			},
+			expected:      []string{"amd64", "ppc64le", "s390x"},
+			expectedMulti: true,
+		},
+		{
+			name: "missing label falls back to node info",
+			nodes: []runtime.Object{
+				&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "master-0"},
+					Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: "arm64"}},
+				},
+			},
+			expected: []string{"arm64"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.nodes...)
+			architectures, err := getNodeArchitectures(client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !architectures.Equal(ksets.NewString(test.expected...)) {
+				t.Errorf("expected %v, got %v", test.expected, architectures.List())
+			}
+			if multi := architectures.Len() > 1; multi != test.expectedMulti {
+				t.Errorf("expected multi-arch %v, got %v", test.expectedMulti, multi)
+			}
+		})
+	}
+}
+
+func TestImageForArchFromManifests(t *testing.T) {
+	const (
+		listDigest  = "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+		amd64Digest = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		arm64Digest = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	)
+	manifests := []imagev1.ImageManifest{
+		{Architecture: "amd64", OS: "linux", Digest: amd64Digest},
+		{Architecture: "arm64", OS: "linux", Digest: arm64Digest},
+	}
+	tests := []struct {
+		name            string
+		ref             string
+		manifests       []imagev1.ImageManifest
+		arch            string
+		expected        string
+		expectedFailure bool
+	}{
+		{
+			name:      "tag reference",
+			ref:       "quay.io/openshift/origin-tools:latest",
+			manifests: manifests,
+			arch:      "arm64",
+			expected:  "quay.io/openshift/origin-tools@" + arm64Digest,
+		},
+		{
+			name:      "manifest list digest reference",
+			ref:       "quay.io/openshift/origin-tools@" + listDigest,
+			manifests: manifests,
+			arch:      "amd64",
+			expected:  "quay.io/openshift/origin-tools@" + amd64Digest,
+		},
+		{
+			name:     "single manifest is unchanged",
+			ref:      "quay.io/openshift/origin-tools:latest",
+			arch:     "amd64",
+			expected: "quay.io/openshift/origin-tools:latest",
+		},
+		{
+			name:            "missing architecture",
+			ref:             "quay.io/openshift/origin-tools:latest",
+			manifests:       manifests,
+			arch:            "s390x",
+			expectedFailure: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := reference.Parse(test.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := imageForArchFromManifests(ref, test.manifests, test.arch)
+			if test.expectedFailure {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
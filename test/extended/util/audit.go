@@ -0,0 +1,121 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// auditLogDirs are the node log directories, relative to /var/log, of the API servers whose audit
+// logs SearchAuditLogs reads.
+var auditLogDirs = []string{"kube-apiserver", "openshift-apiserver"}
+
+// rotatedAuditLogTimeLayout is the layout of the rotation time in the name of rotated audit logs,
+// e.g. audit-2024-01-02T03-04-05.678.log.
+const rotatedAuditLogTimeLayout = "2006-01-02T15-04-05.000"
+
+// AuditFilter selects audit events. Empty fields match any value.
+type AuditFilter struct {
+	User      string
+	Verb      string
+	Resource  string
+	Namespace string
+}
+
+// Matches returns true if the event matches every field set in the filter.
+func (f AuditFilter) Matches(event *auditv1.Event) bool {
+	if len(f.User) > 0 && event.User.Username != f.User {
+		return false
+	}
+	if len(f.Verb) > 0 && event.Verb != f.Verb {
+		return false
+	}
+	if len(f.Resource) == 0 && len(f.Namespace) == 0 {
+		return true
+	}
+	if event.ObjectRef == nil {
+		return false
+	}
+	if len(f.Resource) > 0 && event.ObjectRef.Resource != f.Resource {
+		return false
+	}
+	return len(f.Namespace) == 0 || event.ObjectRef.Namespace == f.Namespace
+}
+
+// mayMatch cheaply rules out audit log lines that cannot match before they are decoded.
+func (f AuditFilter) mayMatch(line string) bool {
+	for _, value := range []string{f.User, f.Verb, f.Resource, f.Namespace} {
+		if len(value) > 0 && !strings.Contains(line, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchAuditLogs returns the audit events of the kube-apiserver and openshift-apiserver on every
+// control-plane node that match the filter and were received at or after since. The logs are read
+// through `oc adm node-logs --path=` one line at a time, and rotated logs that predate since are
+// skipped, so that only matching events are held in memory.
+func SearchAuditLogs(oc *CLI, filter AuditFilter, since time.Time) ([]auditv1.Event, error) {
+	nodes, err := GetNodesByRole(oc, "master")
+	if err != nil {
+		return nil, err
+	}
+	var events []auditv1.Event
+	for _, node := range nodes {
+		for _, dir := range auditLogDirs {
+			files, err := oc.AsAdmin().WithoutNamespace().Run("adm").Args("node-logs", node.Name, "--path="+dir+"/").Output()
+			if err != nil {
+				return nil, fmt.Errorf("listing the %s logs of node %s: %w", dir, node.Name, err)
+			}
+			for _, file := range strings.Split(files, "\n") {
+				file = strings.TrimSpace(file)
+				if !isAuditLogInRange(file, since) {
+					continue
+				}
+				logPath := path.Join(dir, file)
+				err := oc.AsAdmin().WithoutNamespace().Run("adm").Args("node-logs", node.Name, "--path="+logPath).streamLines(context.Background(), func(line string) bool {
+					if !filter.mayMatch(line) {
+						return true
+					}
+					event := auditv1.Event{}
+					if err := json.Unmarshal([]byte(line), &event); err != nil {
+						e2e.Logf("Ignoring an audit log line of %s on node %s that cannot be decoded: %v", logPath, node.Name, err)
+						return true
+					}
+					if !event.RequestReceivedTimestamp.Time.Before(since) && filter.Matches(&event) {
+						events = append(events, event)
+					}
+					return true
+				})
+				if err != nil {
+					return nil, fmt.Errorf("reading %s on node %s: %w", logPath, node.Name, err)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// isAuditLogInRange returns true if the file is an audit log that may hold events received at or
+// after since. A rotated log only holds events received before its rotation time.
+func isAuditLogInRange(file string, since time.Time) bool {
+	if file == "audit.log" {
+		return true
+	}
+	if !strings.HasPrefix(file, "audit-") || !strings.HasSuffix(file, ".log") {
+		return false
+	}
+	rotated, err := time.Parse(rotatedAuditLogTimeLayout, strings.TrimSuffix(strings.TrimPrefix(file, "audit-"), ".log"))
+	if err != nil {
+		// keep logs whose rotation time is unknown, they are filtered by event time anyway
+		return true
+	}
+	return !rotated.Before(since)
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestAuditFilterMatches(t *testing.T) {
+	event := &auditv1.Event{
+		User:      authenticationv1.UserInfo{Username: "system:admin"},
+		Verb:      "delete",
+		ObjectRef: &auditv1.ObjectReference{Resource: "secrets", Namespace: "e2e-test"},
+	}
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		event  *auditv1.Event
+		want   bool
+	}{
+		{name: "empty filter", event: event, want: true},
+		{name: "all fields", filter: AuditFilter{User: "system:admin", Verb: "delete", Resource: "secrets", Namespace: "e2e-test"}, event: event, want: true},
+		{name: "other user", filter: AuditFilter{User: "developer"}, event: event},
+		{name: "other verb", filter: AuditFilter{Verb: "get"}, event: event},
+		{name: "other namespace", filter: AuditFilter{Namespace: "default"}, event: event},
+		{name: "non resource request", filter: AuditFilter{Resource: "secrets"}, event: &auditv1.Event{Verb: "get"}},
+		{name: "non resource request without resource filter", filter: AuditFilter{Verb: "get"}, event: &auditv1.Event{Verb: "get"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	filter := AuditFilter{User: "system:admin", Verb: "delete"}
+	if !filter.mayMatch(`{"verb":"delete","user":{"username":"system:admin"}}`) {
+		t.Errorf("expected a line with the user and verb to possibly match")
+	}
+	if filter.mayMatch(`{"verb":"get","user":{"username":"system:admin"}}`) {
+		t.Errorf("expected a line without the verb to be ruled out")
+	}
+}
+
+func TestIsAuditLogInRange(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{file: "audit.log", want: true},
+		{file: "audit-2024-01-02T04-00-00.000.log", want: true},
+		{file: "audit-2024-01-02T02-00-00.000.log"},
+		{file: "audit-unknown.log", want: true},
+		{file: "termination.log"},
+		{file: ""},
+	}
+	for _, tt := range tests {
+		if got := isAuditLogInRange(tt.file, since); got != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.file, tt.want, got)
+		}
+	}
+}
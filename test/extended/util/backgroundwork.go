@@ -0,0 +1,190 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// failOnLeakedBackgroundWork makes TeardownProject fail the spec when background work it started
+// is still running after cleanup, rather than only logging it.
+var failOnLeakedBackgroundWork = os.Getenv("FAIL_ON_LEAKED_BACKGROUND_WORK") == "true"
+
+const (
+	// backgroundWorkGracePeriod is how long TeardownProject waits for the background goroutines of
+	// the spec to end once they are stopped.
+	backgroundWorkGracePeriod = time.Second
+	// backgroundWorkTimeout replaces backgroundWorkGracePeriod when leaked background work fails the
+	// spec, so that slow goroutines are not reported.
+	backgroundWorkTimeout = 10 * time.Second
+)
+
+// backgroundWork tracks the processes started by Background and the goroutines of the helpers
+// that run until they are stopped, so that the ones left running can be reported. It is shared
+// by the copies of a CLI, and a nil *backgroundWork tracks nothing.
+type backgroundWork struct {
+	lock    sync.Mutex
+	next    int
+	entries map[int]*backgroundWorkEntry
+}
+
+type backgroundWorkEntry struct {
+	description string
+	// alive reports whether a process is still running, goroutines are running until removed
+	alive func() bool
+	// stop asks the goroutine to end, or kills the process
+	stop func()
+	// release frees what the process held once it ended, it may be nil
+	release func()
+}
+
+func newBackgroundWork() *backgroundWork {
+	return &backgroundWork{entries: map[int]*backgroundWorkEntry{}}
+}
+
+// trackGoroutine registers a goroutine that stop asks to end, it must call the returned func when
+// it ends.
+func (w *backgroundWork) trackGoroutine(description string, stop func()) (done func()) {
+	if w == nil {
+		return func() {}
+	}
+	id := w.add(&backgroundWorkEntry{description: "goroutine: " + description, stop: stop})
+	return func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		delete(w.entries, id)
+	}
+}
+
+// trackProcess registers a started process, which is running until it was waited for. A process
+// that was killed but not waited for is reported, since it still holds its PID. release, if not
+// nil, is called once the process is found to have ended.
+func (w *backgroundWork) trackProcess(cmd *exec.Cmd, description string, release func()) {
+	if w == nil {
+		return
+	}
+	w.add(&backgroundWorkEntry{
+		description: fmt.Sprintf("process %d: %s", cmd.Process.Pid, description),
+		alive: func() bool {
+			return cmd.Process.Signal(syscall.Signal(0)) == nil
+		},
+		stop: func() {
+			cmd.Process.Kill()
+		},
+		release: release,
+	})
+}
+
+func (w *backgroundWork) add(entry *backgroundWorkEntry) int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.next++
+	w.entries[w.next] = entry
+	return w.next
+}
+
+// outstanding returns the description of the work still running, in the order it was started,
+// and forgets the processes that ended.
+func (w *backgroundWork) outstanding() []string {
+	if w == nil {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var ids []int
+	for id, entry := range w.entries {
+		if entry.alive != nil && !entry.alive() {
+			if entry.release != nil {
+				entry.release()
+			}
+			delete(w.entries, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	var descriptions []string
+	for _, id := range ids {
+		descriptions = append(descriptions, w.entries[id].description)
+	}
+	return descriptions
+}
+
+// stopAndWait kills the processes still running and stops the goroutines, then returns the
+// processes that were killed and the goroutines that did not end within timeout. Killed processes
+// are no longer tracked, since they hold their PID until waited for.
+func (w *backgroundWork) stopAndWait(timeout time.Duration) []string {
+	if w == nil {
+		return nil
+	}
+	var killed []string
+	var stops []func()
+	w.lock.Lock()
+	var ids []int
+	for id := range w.entries {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		entry := w.entries[id]
+		if entry.alive == nil {
+			stops = append(stops, entry.stop)
+			continue
+		}
+		if entry.alive() {
+			entry.stop()
+			killed = append(killed, entry.description+" (killed)")
+		}
+		if entry.release != nil {
+			entry.release()
+		}
+		delete(w.entries, id)
+	}
+	w.lock.Unlock()
+	for _, stop := range stops {
+		stop()
+	}
+
+	var remaining []string
+	pollUntilTimeout(context.Background(), 100*time.Millisecond, timeout, true, func(context.Context) (bool, error) {
+		remaining = w.outstanding()
+		return len(remaining) == 0, nil
+	})
+	return append(killed, remaining...)
+}
+
+// OutstandingBackgroundWork returns the processes started by Background that were not waited
+// for and the goroutines of helpers such as WatchResource and PortForwardToPod that were not
+// stopped yet, in the order they were started. TeardownProject reports the ones still running
+// after cleanup, and fails the spec when FAIL_ON_LEAKED_BACKGROUND_WORK is true.
+func (c *CLI) OutstandingBackgroundWork() []string {
+	return c.backgroundWork.outstanding()
+}
+
+// reportLeakedBackgroundWork stops the background work of the spec and reports the work that was
+// still running.
+func (c *CLI) reportLeakedBackgroundWork() {
+	timeout := backgroundWorkGracePeriod
+	if failOnLeakedBackgroundWork {
+		timeout = backgroundWorkTimeout
+	}
+	leaked := c.backgroundWork.stopAndWait(timeout)
+	if len(leaked) == 0 {
+		return
+	}
+	message := fmt.Sprintf("%d background processes or goroutines are still running after cleanup:\n%s", len(leaked), strings.Join(leaked, "\n"))
+	framework.Logf("%s", message)
+	if failOnLeakedBackgroundWork {
+		g.Fail(message)
+	}
+}
@@ -0,0 +1,112 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutstandingBackgroundProcesses(t *testing.T) {
+	c := &CLI{execPath: "sh", globalArgs: []string{"-c", "exec sleep 30"}, stdin: &bytes.Buffer{}, backgroundWork: newBackgroundWork()}
+	waited, _, _, err := c.Background()
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaked, _, _, err := c.Background()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer leaked.Wait()
+	defer leaked.Process.Kill()
+
+	outstanding := c.OutstandingBackgroundWork()
+	if len(outstanding) != 2 || !strings.HasPrefix(outstanding[0], "process ") || !strings.HasSuffix(outstanding[0], "sh -c exec sleep 30") {
+		t.Fatalf("expected both processes to be outstanding, got %q", outstanding)
+	}
+
+	waited.Process.Kill()
+	waited.Wait()
+	// killed without being waited for, the process still holds its PID
+	leaked.Process.Kill()
+	if outstanding := c.OutstandingBackgroundWork(); len(outstanding) != 1 || !strings.HasPrefix(outstanding[0], fmt.Sprintf("process %d:", leaked.Process.Pid)) {
+		t.Errorf("expected only the process that was not waited for to be outstanding, got %q", outstanding)
+	}
+}
+
+func TestOutstandingBackgroundGoroutines(t *testing.T) {
+	work := newBackgroundWork()
+
+	stopped := make(chan struct{})
+	done := work.trackGoroutine("well behaved", func() { close(stopped) })
+	go func() {
+		defer done()
+		<-stopped
+	}()
+
+	ignored := make(chan struct{})
+	defer close(ignored)
+	leakedDone := work.trackGoroutine("ignores stop", func() {})
+	go func() {
+		defer leakedDone()
+		<-ignored
+	}()
+
+	if outstanding := work.outstanding(); len(outstanding) != 2 {
+		t.Fatalf("expected both goroutines to be outstanding, got %q", outstanding)
+	}
+	leaked := work.stopAndWait(time.Second)
+	if len(leaked) != 1 || leaked[0] != "goroutine: ignores stop" {
+		t.Errorf("expected only the goroutine ignoring stop to leak, got %q", leaked)
+	}
+
+	// a CLI built without tracking reports nothing
+	if outstanding := (&CLI{}).OutstandingBackgroundWork(); len(outstanding) != 0 {
+		t.Errorf("unexpected outstanding work %q", outstanding)
+	}
+}
+
+func TestBackgroundProcessReleased(t *testing.T) {
+	work := newBackgroundWork()
+	cmd := exec.Command("sh", "-c", "true")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	released := false
+	work.trackProcess(cmd, "sh -c true", func() { released = true })
+
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if outstanding := work.outstanding(); len(outstanding) != 0 {
+		t.Fatalf("unexpected outstanding work %q", outstanding)
+	}
+	if !released {
+		t.Errorf("expected the process to be released once it ended")
+	}
+}
+
+func TestStopAndWaitKillsProcesses(t *testing.T) {
+	c := &CLI{execPath: "sh", globalArgs: []string{"-c", "exec sleep 30"}, stdin: &bytes.Buffer{}, backgroundWork: newBackgroundWork()}
+	leaked, _, _, err := c.Background()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	stopped := c.backgroundWork.stopAndWait(backgroundWorkTimeout)
+	if elapsed := time.Since(start); elapsed >= backgroundWorkTimeout {
+		t.Errorf("expected the killed process not to be waited for, waited %s", elapsed)
+	}
+	if len(stopped) != 1 || !strings.HasPrefix(stopped[0], fmt.Sprintf("process %d:", leaked.Process.Pid)) || !strings.HasSuffix(stopped[0], "(killed)") {
+		t.Errorf("expected the process to be reported as killed, got %q", stopped)
+	}
+	if err := leaked.Wait(); err == nil {
+		t.Errorf("expected the process to have been killed")
+	}
+	if outstanding := c.OutstandingBackgroundWork(); len(outstanding) != 0 {
+		t.Errorf("unexpected outstanding work %q", outstanding)
+	}
+}
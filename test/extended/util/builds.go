@@ -0,0 +1,113 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	buildv1 "github.com/openshift/api/build/v1"
+)
+
+// WaitForBuildComplete waits for the named build to reach the Complete phase and returns it.
+// If the build fails, errors or is cancelled, the returned error carries the
+// build's failure reason and logs.
+func (c *CLI) WaitForBuildComplete(namespace, name string, timeout time.Duration) (*buildv1.Build, error) {
+	var build *buildv1.Build
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		b, err := c.BuildClient().BuildV1().Builds(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			e2e.Logf("Unable to retrieve build %s/%s: %v", namespace, name, err)
+			return false, nil
+		}
+		build = b
+		switch build.Status.Phase {
+		case buildv1.BuildPhaseComplete:
+			return true, nil
+		case buildv1.BuildPhaseFailed, buildv1.BuildPhaseError, buildv1.BuildPhaseCancelled:
+			return false, fmt.Errorf("build %s/%s finished with phase %s", namespace, name, build.Status.Phase)
+		default:
+			return false, nil
+		}
+	})
+	if err == nil {
+		return build, nil
+	}
+	if build == nil {
+		return nil, fmt.Errorf("timed out waiting for build %s/%s: %w", namespace, name, err)
+	}
+	logs, logsErr := c.buildLogs(namespace, name)
+	if logsErr != nil {
+		logs = fmt.Sprintf("<unable to retrieve build logs: %v>", logsErr)
+	}
+	return build, fmt.Errorf("build %s/%s did not complete (phase=%s, reason=%s, message=%q): %w\nBuild logs>\n%s",
+		namespace, name, build.Status.Phase, build.Status.Reason, build.Status.Message, err, logs)
+}
+
+// buildLogs returns the logs of the named build through the build log subresource.
+func (c *CLI) buildLogs(namespace, name string) (string, error) {
+	logs, err := c.BuildClient().BuildV1().RESTClient().Get().
+		Namespace(namespace).
+		Resource("builds").
+		Name(name).
+		SubResource("log").
+		DoRaw(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
+
+// buildFollowTimeout bounds how long StartBuildAndFollow waits for a build to finish.
+const buildFollowTimeout = 15 * time.Minute
+
+// StartBuildAndFollow instantiates a build from the BuildConfig, streams its logs
+// until the build finishes and returns the captured logs together with the
+// terminal build. An error is returned if the build does not complete.
+func (c *CLI) StartBuildAndFollow(namespace, buildConfig string) (logs string, result *buildv1.Build, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildFollowTimeout)
+	defer cancel()
+
+	build, err := c.BuildClient().BuildV1().BuildConfigs(namespace).Instantiate(ctx, buildConfig, &buildv1.BuildRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: buildConfig},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to start a build from buildconfig %s/%s: %w", namespace, buildConfig, err)
+	}
+	e2e.Logf("Started build %s/%s, following its logs", namespace, build.Name)
+
+	var buf bytes.Buffer
+	// the log subresource refuses to stream until the build pod has started
+	err = wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		stream, err := c.BuildClient().BuildV1().RESTClient().Get().
+			Namespace(namespace).
+			Resource("builds").
+			Name(build.Name).
+			SubResource("log").
+			Param("follow", "true").
+			Stream(ctx)
+		if err != nil {
+			e2e.Logf("Unable to stream logs of build %s/%s yet: %v", namespace, build.Name, err)
+			return false, nil
+		}
+		defer stream.Close()
+		if _, err := io.Copy(io.MultiWriter(&buf, g.GinkgoWriter), stream); err != nil {
+			e2e.Logf("Log stream of build %s/%s ended with: %v", namespace, build.Name, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return buf.String(), build, fmt.Errorf("unable to follow the logs of build %s/%s: %w", namespace, build.Name, err)
+	}
+
+	deadline, _ := ctx.Deadline()
+	result, err = c.WaitForBuildComplete(namespace, build.Name, time.Until(deadline))
+	return buf.String(), result, err
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"fmt"
+
+	o "github.com/onsi/gomega"
+
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// HasCapability returns true if the capability is listed in the enabled capabilities
+// of the ClusterVersion status, as IsCapabilityEnabled does.
+func HasCapability(oc *CLI, cap configv1.ClusterVersionCapability) (bool, error) {
+	enabled, err := IsCapabilityEnabled(oc, cap)
+	if err != nil {
+		return false, fmt.Errorf("failure getting test cluster ClusterVersion: %w", err)
+	}
+	return enabled, nil
+}
+
+// SkipUnlessCapability skips the test if the capability is not enabled. The test fails if
+// the capabilities cannot be read.
+func SkipUnlessCapability(oc *CLI, cap configv1.ClusterVersionCapability) {
+	enabled, err := HasCapability(oc, cap)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the %v capability is enabled", cap)
+	if !enabled {
+		skipper.Skipf("Skipping because the %v capability is not enabled", cap)
+	}
+}
+
+// SkipIfCapability skips the test if the capability is enabled. The test fails if the
+// capabilities cannot be read.
+func SkipIfCapability(oc *CLI, cap configv1.ClusterVersionCapability) {
+	enabled, err := HasCapability(oc, cap)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the %v capability is enabled", cap)
+	if enabled {
+		skipper.Skipf("Skipping because the %v capability is enabled", cap)
+	}
+}
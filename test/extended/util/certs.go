@@ -0,0 +1,143 @@
+package util
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testCALifetime is the validity of the CAs generated by GenerateTestCA, certificates signed by
+// them do not outlive it.
+const testCALifetime = 24 * time.Hour
+
+// TestCA is a throwaway certificate authority for tests.
+type TestCA struct {
+	// CertPEM and KeyPEM are the PEM encoded certificate and private key of the CA.
+	CertPEM []byte
+	KeyPEM  []byte
+
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+// CertOption customizes a certificate signed by SignServingCert.
+type CertOption func(*x509.Certificate)
+
+// WithClientAuth adds the client authentication extended key usage, for certificates used by both
+// ends of mTLS connections.
+func WithClientAuth() CertOption {
+	return func(cert *x509.Certificate) {
+		cert.ExtKeyUsage = append(cert.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+	}
+}
+
+// GenerateTestCA returns a new self-signed CA valid for a day.
+func GenerateTestCA(commonName string) (*TestCA, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	template, err := certificateTemplate(commonName, testCALifetime)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CA %s: %w", commonName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &TestCA{
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		cert:    cert,
+		key:     key,
+	}, nil
+}
+
+// SignServingCert returns the PEM encoded certificate and private key of a serving certificate
+// for hosts, which may be DNS names or IP addresses, signed by ca and valid for ttl. The first host
+// is the common name. The ttl may be as short as a few seconds for expiry tests, the certificate
+// is valid from a minute ago to tolerate clock skew. It expires no later than ca.
+func SignServingCert(ca *TestCA, hosts []string, ttl time.Duration, opts ...CertOption) (certPEM, keyPEM []byte, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, fmt.Errorf("a serving certificate needs at least one host")
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := certificateTemplate(hosts[0], ttl)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	for _, opt := range opts {
+		opt(template)
+	}
+	if template.NotAfter.After(ca.cert.NotAfter) {
+		template.NotAfter = ca.cert.NotAfter
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to sign certificate for %v: %w", hosts, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		nil
+}
+
+// CreateTLSSecret creates a kubernetes.io/tls secret holding cert and key, which is deleted when
+// the test ends.
+func CreateTLSSecret(oc *CLI, ns, name string, cert, key []byte) (*corev1.Secret, error) {
+	secret, err := oc.AdminKubeClient().CoreV1().Secrets(ns).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: key,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	oc.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("secrets"), secret)
+	return secret, nil
+}
+
+func certificateTemplate(commonName string, ttl time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(ttl),
+	}, nil
+}
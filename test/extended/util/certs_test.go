@@ -0,0 +1,73 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func TestSignServingCert(t *testing.T) {
+	ca, err := GenerateTestCA("test-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := SignServingCert(ca, []string{"app.example.com", "10.0.0.1"}, time.Hour, WithClientAuth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "app.example.com" || len(cert.DNSNames) != 1 || len(cert.IPAddresses) != 1 || cert.IPAddresses[0].String() != "10.0.0.1" {
+		t.Errorf("unexpected subject %s, DNS names %v and IP addresses %v", cert.Subject, cert.DNSNames, cert.IPAddresses)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca.CertPEM) {
+		t.Fatal("unable to load the CA")
+	}
+	for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: "app.example.com", Roots: roots, KeyUsages: []x509.ExtKeyUsage{usage}}); err != nil {
+			t.Errorf("unable to verify the certificate for usage %v: %v", usage, err)
+		}
+	}
+
+	// a short lived certificate is expired once its ttl elapsed
+	certPEM, _, err = SignServingCert(ca, []string{"app.example.com"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: time.Now().Add(time.Minute)}); err == nil {
+		t.Errorf("expected the certificate to be expired")
+	}
+
+	// a certificate does not outlive the CA
+	certPEM, _, err = SignServingCert(ca, []string{"app.example.com"}, 2*testCALifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ = pem.Decode(certPEM)
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cert.NotAfter.Equal(ca.cert.NotAfter) {
+		t.Errorf("expected the certificate to expire with the CA at %s, expires at %s", ca.cert.NotAfter, cert.NotAfter)
+	}
+
+	if _, _, err := SignServingCert(ca, nil, time.Hour); err == nil {
+		t.Errorf("expected an error without hosts")
+	}
+}
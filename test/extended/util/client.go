@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/rand"
@@ -9,16 +10,20 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	g "github.com/onsi/ginkgo/v2"
@@ -35,9 +40,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/types"
+	kutilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/storage/names"
 	memory "k8s.io/client-go/discovery/cached"
@@ -74,6 +81,7 @@ import (
 	securityv1client "github.com/openshift/client-go/security/clientset/versioned"
 	templatev1client "github.com/openshift/client-go/template/clientset/versioned"
 	userv1client "github.com/openshift/client-go/user/clientset/versioned"
+	userv1typedclient "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
 	"github.com/openshift/library-go/test/library/metrics"
 )
 
@@ -84,6 +92,10 @@ type CLI struct {
 	verb            string
 	configPath      string
 	adminConfigPath string
+	// kubeContext selects a context of the kubeconfig, the current context is used when empty
+	kubeContext string
+	// serverURL overrides the API server of the kubeconfig when set
+	serverURL string
 
 	// directory with static manifests, each file is expected to be a single manifest
 	// manifest files can be stored under directory tree
@@ -96,6 +108,7 @@ type CLI struct {
 	finalArgs            []string
 	namespacesToDelete   []string
 	stdin                *bytes.Buffer
+	stdinReader          io.Reader
 	stdout               io.Writer
 	stderr               io.Writer
 	verbose              bool
@@ -103,9 +116,47 @@ type CLI struct {
 	withManagedNamespace bool
 	kubeFramework        *framework.Framework
 
+	// when set, the command is killed once specCtx is done or the deadline passes
+	specCtx        context.Context
+	deadline       time.Time
+	cancelDeadline context.CancelFunc
+
 	// read from a static manifest directory (set through STATIC_CONFIG_MANIFEST_DIR env)
 	configObjects     []runtime.Object
 	resourcesToDelete []resourceRef
+	pathsToDelete     []string
+
+	// shared by the copies of the CLI, see OutstandingBackgroundWork
+	backgroundWork *backgroundWork
+}
+
+// SetupProjectRoleBindingTimeout is how long SetupProject waits for each of the default
+// role bindings of a new project to be provisioned.
+var SetupProjectRoleBindingTimeout = 3 * time.Minute
+
+// CLIOption configures the CLI when it is constructed.
+type CLIOption func(*CLI)
+
+// WithClientQPS sets the QPS of the clients created by the underlying Kube
+// framework. It does not affect the user config, which is never rate limited.
+func WithClientQPS(qps float32) CLIOption {
+	return func(c *CLI) {
+		c.kubeFramework.Options.ClientQPS = qps
+	}
+}
+
+// WithClientBurst sets the burst of the clients created by the underlying Kube
+// framework. It does not affect the user config, which is never rate limited.
+func WithClientBurst(burst int) CLIOption {
+	return func(c *CLI) {
+		c.kubeFramework.Options.ClientBurst = burst
+	}
+}
+
+func (c *CLI) applyOptions(opts []CLIOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
 }
 
 type resourceRef struct {
@@ -123,6 +174,7 @@ func NewCLIWithFramework(kubeFramework *framework.Framework) *CLI {
 		execPath:                "oc",
 		adminConfigPath:         KubeConfigPath(),
 		staticConfigManifestDir: StaticConfigManifestDir(),
+		backgroundWork:          newBackgroundWork(),
 	}
 	// Called only once (assumed the objects will never get modified)
 	// TODO: run in every BeforeEach
@@ -132,8 +184,8 @@ func NewCLIWithFramework(kubeFramework *framework.Framework) *CLI {
 
 // NewCLIWithPodSecurityLevel initializes the CLI the same way as `NewCLI()`
 // but the given pod security level is applied to the created e2e test namespace.
-func NewCLIWithPodSecurityLevel(project string, level admissionapi.Level) *CLI {
-	cli := NewCLI(project)
+func NewCLIWithPodSecurityLevel(project string, level admissionapi.Level, opts ...CLIOption) *CLI {
+	cli := NewCLI(project, opts...)
 	cli.kubeFramework.NamespacePodSecurityLevel = level
 	return cli
 }
@@ -141,8 +193,8 @@ func NewCLIWithPodSecurityLevel(project string, level admissionapi.Level) *CLI {
 // NewCLI initializes the CLI and Kube framework helpers with the provided
 // namespace. Should be called outside of a Ginkgo .It() function.
 // This will apply the `restricted` pod security level to the given underlying namespace.
-func NewCLI(project string) *CLI {
-	cli := NewCLIWithoutNamespace(project)
+func NewCLI(project string, opts ...CLIOption) *CLI {
+	cli := NewCLIWithoutNamespace(project, opts...)
 	cli.withoutNamespace = false
 	// create our own project
 	g.BeforeEach(func() { cli.SetupProject() })
@@ -152,7 +204,7 @@ func NewCLI(project string) *CLI {
 // NewCLIWithoutNamespace initializes the CLI and Kube framework helpers
 // without a namespace. Should be called outside of a Ginkgo .It()
 // function. Use SetupProject() to create a project for this namespace.
-func NewCLIWithoutNamespace(project string) *CLI {
+func NewCLIWithoutNamespace(project string, opts ...CLIOption) *CLI {
 	cli := &CLI{
 		kubeFramework: &framework.Framework{
 			SkipNamespaceCreation: true,
@@ -168,7 +220,9 @@ func NewCLIWithoutNamespace(project string) *CLI {
 		adminConfigPath:         KubeConfigPath(),
 		staticConfigManifestDir: StaticConfigManifestDir(),
 		withoutNamespace:        true,
+		backgroundWork:          newBackgroundWork(),
 	}
+	cli.applyOptions(opts)
 	g.BeforeEach(cli.kubeFramework.BeforeEach)
 
 	// Called only once (assumed the objects will never get modified)
@@ -185,7 +239,7 @@ func NewCLIWithoutNamespace(project string) *CLI {
 // NewCLIForMonitorTest initializes the CLI and Kube framework helpers
 // without a namespace. Should be called outside of a Ginkgo .It()
 // function.
-func NewCLIForMonitorTest(project string) *CLI {
+func NewCLIForMonitorTest(project string, opts ...CLIOption) *CLI {
 	cli := &CLI{
 		kubeFramework: &framework.Framework{
 			SkipNamespaceCreation: true,
@@ -201,7 +255,9 @@ func NewCLIForMonitorTest(project string) *CLI {
 		adminConfigPath:         KubeConfigPath(),
 		staticConfigManifestDir: StaticConfigManifestDir(),
 		withoutNamespace:        true,
+		backgroundWork:          newBackgroundWork(),
 	}
+	cli.applyOptions(opts)
 
 	// Called only once (assumed the objects will never get modified)
 	cli.setupStaticConfigsFromManifests()
@@ -213,10 +269,10 @@ func NewCLIForMonitorTest(project string) *CLI {
 // operations. Also, contrary to a normal CLI it must be constructed inside an `It` block. This is
 // because retrieval of hypershift management cluster config can fail, but assertions are only
 // allowed inside an `It` block. `AfterEach` and `BeforeEach` are not allowed there though.
-func NewHypershiftManagementCLI(project string) *CLI {
+func NewHypershiftManagementCLI(project string, opts ...CLIOption) *CLI {
 	kubeconfig, _, err := GetHypershiftManagementClusterConfigAndNamespace()
 	o.Expect(err).NotTo(o.HaveOccurred())
-	return &CLI{
+	cli := &CLI{
 		kubeFramework: &framework.Framework{
 			SkipNamespaceCreation: true,
 			BaseName:              project,
@@ -230,7 +286,10 @@ func NewHypershiftManagementCLI(project string) *CLI {
 		execPath:         "oc",
 		adminConfigPath:  kubeconfig,
 		withoutNamespace: true,
+		backgroundWork:   newBackgroundWork(),
 	}
+	cli.applyOptions(opts)
+	return cli
 }
 
 // KubeFramework returns Kubernetes framework which contains helper functions
@@ -252,6 +311,71 @@ func (c *CLI) AsAdmin() *CLI {
 	return &nc
 }
 
+// WithContext returns a copy of the CLI that uses the named context of its admin
+// kubeconfig, both for the clients it builds and for the commands it runs. This allows
+// using a kubeconfig holding several clusters, e.g. a management and a guest cluster.
+func (c *CLI) WithContext(name string) *CLI {
+	nc := *c
+	nc.kubeContext = name
+	return &nc
+}
+
+// contextFor returns the context set with WithContext when path is the admin kubeconfig. The
+// kubeconfigs generated for project users only hold their own context, which is used as is.
+func (c *CLI) contextFor(path string) string {
+	if path != c.adminConfigPath {
+		return ""
+	}
+	return c.kubeContext
+}
+
+// WithServerURL returns a copy of the CLI that sends its requests to the API server at url instead
+// of the one in its kubeconfig files, both for the clients it builds and for the commands it runs,
+// e.g. to pin requests to a single control-plane instance. The serving certificate is still
+// verified against the host name of the kubeconfig, so that url may be an IP address.
+func (c *CLI) WithServerURL(url string) *CLI {
+	nc := *c
+	nc.serverURL = url
+	return &nc
+}
+
+// overrideServer points config at the server set with WithServerURL, if any.
+func (c *CLI) overrideServer(config *rest.Config) *rest.Config {
+	if len(c.serverURL) == 0 {
+		return config
+	}
+	if len(config.TLSClientConfig.ServerName) == 0 {
+		config.TLSClientConfig.ServerName = serverNameFromHost(config.Host)
+	}
+	config.Host = c.serverURL
+	return config
+}
+
+// kubeconfigServerName returns the host name of the API server of the kubeconfig used by the
+// commands, or an empty string if it cannot be read.
+func (c *CLI) kubeconfigServerName() string {
+	path := c.configPath
+	if len(path) == 0 {
+		path = c.adminConfigPath
+	}
+	config, err := getClientConfig(path, c.contextFor(path))
+	if err != nil {
+		return ""
+	}
+	return serverNameFromHost(config.Host)
+}
+
+// serverNameFromHost returns the host name of an API server URL or host:port.
+func serverNameFromHost(host string) string {
+	if u, err := url.Parse(host); err == nil && len(u.Hostname()) > 0 {
+		return u.Hostname()
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
 // ChangeUser changes the user used by the current CLI session.
 func (c *CLI) ChangeUser(name string) *CLI {
 	requiresTestStart()
@@ -277,6 +401,26 @@ func (c *CLI) ChangeUser(name string) *CLI {
 	return c
 }
 
+// useAdminCredentials writes a copy of the admin config scoped to the current
+// namespace and uses it as the config of the current CLI session.
+func (c *CLI) useAdminCredentials() {
+	kubeConfig, err := createConfig(c.Namespace(), c.AdminConfig())
+	if err != nil {
+		FatalErr(err)
+	}
+
+	f, err := ioutil.TempFile("", "configfile")
+	if err != nil {
+		FatalErr(err)
+	}
+	c.configPath = f.Name()
+	err = clientcmd.WriteToFile(*kubeConfig, c.configPath)
+	if err != nil {
+		FatalErr(err)
+	}
+	framework.Logf("configPath is now %q", c.configPath)
+}
+
 // SetNamespace sets a new namespace
 func (c *CLI) SetNamespace(ns string) *CLI {
 	c.kubeFramework.Namespace = &corev1.Namespace{
@@ -312,15 +456,23 @@ func (c CLI) WithToken(token string) *CLI {
 func (c *CLI) SetupProject() string {
 	exist, err := DoesApiResourceExist(c.AdminConfig(), "projects", "project.openshift.io")
 	o.Expect(err).ToNot(o.HaveOccurred())
+	var namespace string
 	if exist {
-		return c.setupProject()
+		namespace = c.setupProject()
+	} else {
+		// MicroShift lacks the OAuth stack and SCC namespace annotations, so the
+		// namespace is provisioned and used with the admin credentials instead.
+		isMicroShift, err := IsMicroShiftCluster(c.AdminKubeClient())
+		o.Expect(err).ToNot(o.HaveOccurred())
+		namespace = c.setupNamespace(isMicroShift)
 	}
-	return c.setupNamespace()
+	c.recordTestBoundaryEvent("Started")
+	return namespace
 }
 
 func (c *CLI) setupProject() string {
 	requiresTestStart()
-	newNamespace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("e2e-test-%s-", c.kubeFramework.BaseName))
+	newNamespace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s%s-", testNamespacePrefix, c.kubeFramework.BaseName))
 	c.SetNamespace(newNamespace).ChangeUser(fmt.Sprintf("%s-user", newNamespace))
 	framework.Logf("The user is now %q", c.Username())
 
@@ -358,6 +510,14 @@ func (c *CLI) setupProject() string {
 	}
 	defaultRoleBindings := []string{"system:image-pullers", "system:image-builders"}
 
+	buildEnabled, err := IsCapabilityEnabled(c, configv1.ClusterVersionCapabilityBuild)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	if !buildEnabled {
+		framework.Logf("%v capability is not enabled, removing 'builder' SA from the list of default SAs", configv1.ClusterVersionCapabilityBuild)
+		DefaultServiceAccounts = []string{"default"}
+		defaultRoleBindings = []string{"system:image-pullers"}
+	}
+
 	dcEnabled, err := IsCapabilityEnabled(c, configv1.ClusterVersionCapabilityDeploymentConfig)
 	o.Expect(err).NotTo(o.HaveOccurred())
 	if dcEnabled {
@@ -388,7 +548,7 @@ func (c *CLI) setupProject() string {
 	for _, name := range defaultRoleBindings {
 		framework.Logf("Waiting for RoleBinding %q to be provisioned...", name)
 
-		ctx, cancel = watchtools.ContextWithOptionalTimeout(context.Background(), 3*time.Minute)
+		ctx, cancel = watchtools.ContextWithOptionalTimeout(context.Background(), SetupProjectRoleBindingTimeout)
 
 		fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
 		lw := &cache.ListWatch{
@@ -423,9 +583,12 @@ func (c *CLI) setupProject() string {
 	return newNamespace
 }
 
-func (c *CLI) setupNamespace() string {
+// setupNamespace creates a plain namespace for clusters without the project API.
+// When asAdmin is true the CLI keeps using the admin credentials rather than
+// provisioning a dedicated user.
+func (c *CLI) setupNamespace(asAdmin bool) string {
 	requiresTestStart()
-	newNamespace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("e2e-test-%s-", c.kubeFramework.BaseName))
+	newNamespace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s%s-", testNamespacePrefix, c.kubeFramework.BaseName))
 	username := fmt.Sprintf("%s-user", newNamespace)
 	serviceAccountName := "default"
 	c.SetNamespace(newNamespace)
@@ -449,6 +612,17 @@ func (c *CLI) setupNamespace() string {
 	err = WaitForServiceAccount(c.AdminKubeClient().CoreV1().ServiceAccounts(newNamespace), serviceAccountName)
 	o.Expect(err).NotTo(o.HaveOccurred())
 
+	if asAdmin {
+		framework.Logf("Configuring kubeconfig with admin credentials...")
+		c.useAdminCredentials()
+
+		err = c.setupNamespacePodSecurity(newNamespace)
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		framework.Logf("Namespace %q has been fully provisioned.", newNamespace)
+		return newNamespace
+	}
+
 	framework.Logf("Configuring kubeconfig with user %q certificates...", username)
 	c.ChangeUser(username)
 
@@ -561,7 +735,7 @@ func (c *CLI) setupUserConfig(username string) (*rest.Config, error) {
 		return nil, err
 	}
 
-	pollErr := wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
+	pollErr := poll(time.Second, time.Minute, true, func() (bool, error) {
 		csr, err = c.AdminKubeClient().CertificatesV1().CertificateSigningRequests().Get(context.Background(), username, metav1.GetOptions{})
 		if err != nil {
 			return false, err
@@ -624,6 +798,7 @@ func (c *CLI) setupNamespacePodSecurity(ns string) error {
 
 // TeardownProject removes projects created by this test.
 func (c *CLI) TeardownProject() {
+	c.recordTestBoundaryEvent("Finished")
 	if len(c.Namespace()) > 0 && g.CurrentSpecReport().Failed() && framework.TestContext.DumpLogsOnFailure {
 		e2edebug.DumpAllNamespaceInfo(context.TODO(), c.kubeFramework.ClientSet, c.Namespace())
 	}
@@ -631,12 +806,19 @@ func (c *CLI) TeardownProject() {
 	if len(c.configPath) > 0 {
 		os.Remove(c.configPath)
 	}
+	for _, path := range c.pathsToDelete {
+		if err := os.RemoveAll(path); err != nil {
+			framework.Logf("Unable to remove %s: %v", path, err)
+		}
+	}
 
 	dynamicClient := c.AdminDynamicClient()
 	for _, resource := range c.resourcesToDelete {
 		err := dynamicClient.Resource(resource.Resource).Namespace(resource.Namespace).Delete(context.Background(), resource.Name, metav1.DeleteOptions{})
 		framework.Logf("Deleted %v, err: %v", resource, err)
 	}
+
+	c.reportLeakedBackgroundWork()
 }
 
 // Verbose turns on printing verbose messages when executing OpenShift commands
@@ -786,7 +968,7 @@ func (c *CLI) NewPrometheusClient(ctx context.Context) prometheusv1.API {
 		lastErr          error
 		prometheusClient prometheusv1.API
 	)
-	err = wait.PollUntilContextTimeout(ctx, time.Second, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+	err = pollUntilTimeout(ctx, time.Second, 10*time.Second, true, func(ctx context.Context) (bool, error) {
 		prometheusClient, err = metrics.NewPrometheusClient(ctx, kubeClient, routeClient)
 		if err != nil {
 			if ctx.Err() == nil {
@@ -806,19 +988,19 @@ func (c *CLI) NewPrometheusClient(ctx context.Context) prometheusv1.API {
 }
 
 func (c *CLI) UserConfig() *rest.Config {
-	clientConfig, err := GetClientConfig(c.configPath)
+	clientConfig, err := getClientConfig(c.configPath, c.contextFor(c.configPath))
 	if err != nil {
 		FatalErr(err)
 	}
-	return clientConfig
+	return c.overrideServer(clientConfig)
 }
 
 func (c *CLI) AdminConfig() *rest.Config {
-	clientConfig, err := GetClientConfig(c.adminConfigPath)
+	clientConfig, err := getClientConfig(c.adminConfigPath, c.contextFor(c.adminConfigPath))
 	if err != nil {
 		FatalErr(err)
 	}
-	return clientConfig
+	return c.overrideServer(clientConfig)
 }
 
 // Namespace returns the name of the namespace used in the current test case.
@@ -848,12 +1030,25 @@ func (c *CLI) Run(commands ...string) *CLI {
 		kubeFramework:   c.KubeFramework(),
 		adminConfigPath: c.adminConfigPath,
 		configPath:      c.configPath,
+		kubeContext:     c.kubeContext,
+		serverURL:       c.serverURL,
 		username:        c.username,
 		globalArgs:      commands,
+		backgroundWork:  c.backgroundWork,
 	}
 	if len(c.configPath) > 0 {
 		nc.globalArgs = append([]string{fmt.Sprintf("--kubeconfig=%s", c.configPath)}, nc.globalArgs...)
 	}
+	if kubeContext := c.contextFor(c.configPath); len(kubeContext) > 0 {
+		nc.globalArgs = append([]string{fmt.Sprintf("--context=%s", kubeContext)}, nc.globalArgs...)
+	}
+	if len(c.serverURL) > 0 {
+		serverArgs := []string{fmt.Sprintf("--server=%s", c.serverURL)}
+		if serverName := c.kubeconfigServerName(); len(serverName) > 0 {
+			serverArgs = append(serverArgs, fmt.Sprintf("--tls-server-name=%s", serverName))
+		}
+		nc.globalArgs = append(serverArgs, nc.globalArgs...)
+	}
 	if len(c.configPath) == 0 && len(c.token) > 0 {
 		nc.globalArgs = append([]string{fmt.Sprintf("--token=%s", c.token)}, nc.globalArgs...)
 	}
@@ -864,6 +1059,45 @@ func (c *CLI) Run(commands ...string) *CLI {
 	return nc.setOutput(c.stdout)
 }
 
+// AdmRun prepares an `oc adm` command, e.g. AdmRun("policy", "add-scc-to-user").
+// The config, context and namespace flags are set the same way as for Run.
+func (c *CLI) AdmRun(subcommands ...string) *CLI {
+	return c.Run(append([]string{"adm"}, subcommands...)...)
+}
+
+// RunWithSpecDeadline is like Run but the command is killed if it is still
+// running shortly before the deadline of ctx, usually the ginkgo.SpecContext
+// of the current spec, or once ctx is done, e.g. when the spec is interrupted.
+// When ctx has no deadline the command only ends with ctx.
+func (c *CLI) RunWithSpecDeadline(ctx context.Context, commands ...string) *CLI {
+	nc := c.Run(commands...)
+	nc.specCtx = ctx
+	nc.deadline = specDeadline(ctx)
+	return nc
+}
+
+// commandContext returns a context derived from ctx that also ends with the spec
+// context and deadline set by RunWithSpecDeadline.
+func (c *CLI) commandContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if c.specCtx == nil {
+		return ctx, cancel
+	}
+	stopAfter := context.AfterFunc(c.specCtx, cancel)
+	if c.deadline.IsZero() {
+		return ctx, func() {
+			stopAfter()
+			cancel()
+		}
+	}
+	ctx, cancelDeadline := context.WithDeadline(ctx, c.deadline)
+	return ctx, func() {
+		stopAfter()
+		cancelDeadline()
+		cancel()
+	}
+}
+
 // Executes with the kubeconfig specified from the environment
 func (c *CLI) RunInMonitorTest(commands ...string) *CLI {
 	in, out, errout := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
@@ -886,6 +1120,21 @@ func (c *CLI) InputString(input string) *CLI {
 	return c
 }
 
+// InputReader streams r to the stdin of the command instead of the input added by InputString,
+// so that large or binary input is not held in memory.
+func (c *CLI) InputReader(r io.Reader) *CLI {
+	c.stdinReader = r
+	return c
+}
+
+// commandStdin returns the stdin of the command.
+func (c *CLI) commandStdin() io.Reader {
+	if c.stdinReader != nil {
+		return c.stdinReader
+	}
+	return c.stdin
+}
+
 // Args sets the additional arguments for the OpenShift CLI command
 func (c *CLI) Args(args ...string) *CLI {
 	c.commandArgs = args
@@ -915,6 +1164,123 @@ func (c *CLI) Outputs() (string, string, error) {
 	return c.outputs(&stdOutBuff, &stdErrBuff)
 }
 
+// ExpectError executes a command that is expected to fail and returns its stderr. An error is
+// returned only if the command unexpectedly succeeds, e.g.
+//
+//	stderr, err := oc.Run("create").Args("-f", invalid).ExpectError()
+//	o.Expect(err).NotTo(o.HaveOccurred())
+//	o.Expect(stderr).To(o.ContainSubstring("is invalid"))
+func (c *CLI) ExpectError() (string, error) {
+	stdout, stderr, err := c.Outputs()
+	if err == nil {
+		return stderr, fmt.Errorf("expected 'oc %s' to fail, but it succeeded:\nStdOut>\n%s\nStdErr>\n%s", c.printCmd(), stdout, stderr)
+	}
+	return stderr, nil
+}
+
+// OutputsStreaming executes the command, passing each line of stdout and stderr to onStdout and
+// onStderr as it is printed, and returns the complete stdout and stderr once the command exits.
+// Either callback may be nil. The callbacks are called from separate goroutines. Cancelling ctx
+// kills the command.
+func (c *CLI) OutputsStreaming(ctx context.Context, onStdout, onStderr func(string)) (stdout, stderr string, err error) {
+	c.finalArgs = append(c.globalArgs, c.commandArgs...)
+	ctx, cancel := c.commandContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.execPath, c.finalArgs...)
+	cmd.Stdin = c.commandStdin()
+	framework.Logf("Running '%s %s'", c.execPath, redactBearerToken(c.finalArgs))
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", "", err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return "", "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", "", err
+	}
+
+	var stdOutBuff, stdErrBuff bytes.Buffer
+	var wg sync.WaitGroup
+	scan := func(r io.Reader, buff *bytes.Buffer, onLine func(string)) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			buff.WriteString(line + "\n")
+			if onLine != nil {
+				onLine(line)
+			}
+		}
+		// drain what the scanner could not handle so that the command does not block
+		io.Copy(buff, r)
+	}
+	wg.Add(2)
+	go scan(stdoutPipe, &stdOutBuff, onStdout)
+	go scan(stderrPipe, &stdErrBuff, onStderr)
+	wg.Wait()
+	err = cmd.Wait()
+
+	stdout = strings.TrimSpace(stdOutBuff.String())
+	stderr = strings.TrimSpace(stdErrBuff.String())
+	if err != nil {
+		if ctx.Err() != nil {
+			return stdout, stderr, fmt.Errorf("command %v was cancelled: %w", cmd, ctx.Err())
+		}
+		return stdout, stderr, fmt.Errorf("Error running %v:\nStdErr>\n%s\n%w", cmd, stderr[getStartingIndexForLastN([]byte(stderr), 4096):], err)
+	}
+	return stdout, stderr, nil
+}
+
+// streamLines executes the command and passes each line of stdout to onLine as it is printed,
+// without retaining it, for commands printing more than should be held in memory. Returning
+// false from onLine kills the command, which is then not an error.
+func (c *CLI) streamLines(ctx context.Context, onLine func(string) bool) error {
+	c.finalArgs = append(c.globalArgs, c.commandArgs...)
+	ctx, cancel := c.commandContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.execPath, c.finalArgs...)
+	cmd.Stdin = c.commandStdin()
+	var stdErrBuff bytes.Buffer
+	cmd.Stderr = &stdErrBuff
+	framework.Logf("Running '%s %s'", c.execPath, redactBearerToken(c.finalArgs))
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	stopped := false
+	scanner := bufio.NewScanner(stdoutPipe)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if !onLine(scanner.Text()) {
+			stopped = true
+			cancel()
+			break
+		}
+	}
+	scanErr := scanner.Err()
+	// drain what is left so that the command does not block on a full pipe
+	io.Copy(io.Discard, stdoutPipe)
+	err = cmd.Wait()
+	switch {
+	case stopped:
+		return nil
+	case err != nil:
+		stderr := strings.TrimSpace(stdErrBuff.String())
+		return fmt.Errorf("Error running %v:\nStdErr>\n%s\n%w", cmd, stderr[getStartingIndexForLastN([]byte(stderr), 4096):], err)
+	case scanErr != nil:
+		return fmt.Errorf("reading the output of %v: %w", cmd, scanErr)
+	}
+	return nil
+}
+
 // Background executes the command in the background and returns the Cmd object
 // which may be killed later via cmd.Process.Kill().  It also returns buffers
 // holding the stdout & stderr of the command, which may be read from only after
@@ -922,6 +1288,9 @@ func (c *CLI) Outputs() (string, string, error) {
 func (c *CLI) Background() (*exec.Cmd, *bytes.Buffer, *bytes.Buffer, error) {
 	var stdOutBuff, stdErrBuff bytes.Buffer
 	cmd, err := c.start(&stdOutBuff, &stdErrBuff)
+	if err == nil {
+		c.backgroundWork.trackProcess(cmd, fmt.Sprintf("%s %s", c.execPath, redactBearerToken(c.finalArgs)), c.cancelDeadline)
+	}
 	return cmd, &stdOutBuff, &stdErrBuff, err
 }
 
@@ -930,14 +1299,24 @@ func (c *CLI) start(stdOutBuff, stdErrBuff *bytes.Buffer) (*exec.Cmd, error) {
 	if c.verbose {
 		fmt.Printf("DEBUG: oc %s\n", c.printCmd())
 	}
-	cmd := exec.Command(c.execPath, c.finalArgs...)
-	cmd.Stdin = c.stdin
+	var cmd *exec.Cmd
+	if c.specCtx != nil {
+		var ctx context.Context
+		ctx, c.cancelDeadline = c.commandContext(context.Background())
+		cmd = exec.CommandContext(ctx, c.execPath, c.finalArgs...)
+	} else {
+		cmd = exec.Command(c.execPath, c.finalArgs...)
+	}
+	cmd.Stdin = c.commandStdin()
 	// Redact any bearer token information from the log.
 	framework.Logf("Running '%s %s'", c.execPath, redactBearerToken(c.finalArgs))
 
 	cmd.Stdout = stdOutBuff
 	cmd.Stderr = stdErrBuff
 	err := cmd.Start()
+	if err != nil && c.cancelDeadline != nil {
+		c.cancelDeadline()
+	}
 
 	return cmd, err
 }
@@ -969,6 +1348,9 @@ func (c *CLI) outputs(stdOutBuff, stdErrBuff *bytes.Buffer) (string, string, err
 		return "", "", err
 	}
 	err = cmd.Wait()
+	if c.cancelDeadline != nil {
+		c.cancelDeadline()
+	}
 
 	stdOutBytes := stdOutBuff.Bytes()
 	stdErrBytes := stdErrBuff.Bytes()
@@ -981,6 +1363,14 @@ func (c *CLI) outputs(stdOutBuff, stdErrBuff *bytes.Buffer) (string, string, err
 		c.stderr = bytes.NewBuffer(stdErrBytes)
 		return stdOut, stdErr, nil
 	case *exec.ExitError:
+		if !c.deadline.IsZero() && !time.Now().Before(c.deadline) {
+			framework.Logf("Command %v was cut off by the spec deadline %s:\nStdOut>\n%s\nStdErr>\n%s\n", cmd, c.deadline.Format(time.RFC3339), stdOut, stdErr)
+			return stdOut, stdErr, fmt.Errorf("command %v was cut off by the spec deadline %s: %w", cmd, c.deadline.Format(time.RFC3339), err)
+		}
+		if c.specCtx != nil && c.specCtx.Err() != nil {
+			framework.Logf("Command %v was killed as the spec ended:\nStdOut>\n%s\nStdErr>\n%s\n", cmd, stdOut, stdErr)
+			return stdOut, stdErr, fmt.Errorf("command %v was killed as the spec ended (%v): %w", cmd, context.Cause(c.specCtx), err)
+		}
 		framework.Logf("Error running %v:\nStdOut>\n%s\nStdErr>\n%s\n", cmd, stdOut, stdErr)
 		wrappedErr := fmt.Errorf("Error running %v:\nStdOut>\n%s\nStdErr>\n%s\n%w\n", cmd, stdOut[getStartingIndexForLastN(stdOutBytes, 4096):], stdErr[getStartingIndexForLastN(stdErrBytes, 4096):], err)
 		return stdOut, stdErr, wrappedErr
@@ -1001,6 +1391,37 @@ func (c *CLI) OutputToFile(filename string) (string, error) {
 	return path, ioutil.WriteFile(path, []byte(content), 0644)
 }
 
+// OutputToArtifactDir executes the command and stores the output in filename under subdir of
+// a directory named after the current test, inside the artifact directory. Unlike OutputToFile,
+// files of different tests sharing a namespace prefix do not collide.
+func (c *CLI) OutputToArtifactDir(subdir, filename string) (string, error) {
+	content, _, err := c.Outputs()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(framework.TestContext.OutputDir, testArtifactDirName(g.CurrentSpecReport().FullText()), subdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filename)
+	return path, ioutil.WriteFile(path, []byte(content), 0644)
+}
+
+var unsafeArtifactDirChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// testArtifactDirName turns a test name into a directory name, replacing the characters that
+// are not safe in paths and keeping it below common file name limits.
+func testArtifactDirName(testName string) string {
+	name := strings.Trim(unsafeArtifactDirChars.ReplaceAllString(testName, "-"), "-.")
+	if len(name) > 200 {
+		name = strings.TrimRight(name[:200], "-.")
+	}
+	if len(name) == 0 {
+		return "unknown-test"
+	}
+	return name
+}
+
 // Execute executes the current command and return error if the execution failed
 // This function will set the default output to Ginkgo writer.
 func (c *CLI) Execute() error {
@@ -1027,6 +1448,72 @@ func (c *CLI) AddResourceToDelete(resource schema.GroupVersionResource, metadata
 	c.resourcesToDelete = append(c.resourcesToDelete, resourceRef{Resource: resource, Namespace: metadata.GetNamespace(), Name: metadata.GetName()})
 }
 
+// TempDir creates a temporary directory under the OS temporary directory, for fixtures a test
+// writes to disk, and registers it for removal along with its content when the project is torn
+// down. Like the resources registered with AddResourceToDelete, it must be created through the CLI
+// of the test rather than a copy, such as the one returned by AsAdmin.
+func (c *CLI) TempDir() (string, error) {
+	prefix := "e2e-"
+	if len(c.Namespace()) > 0 {
+		prefix = c.Namespace() + "-"
+	}
+	dir, err := os.MkdirTemp("", prefix)
+	if err != nil {
+		return "", err
+	}
+	c.pathsToDelete = append(c.pathsToDelete, dir)
+	return dir, nil
+}
+
+// LabelAllCreated sets the label on every resource registered for deletion so far, so that they
+// can be found, or deleted with DeleteByLabel, as a group. Resources that are already gone are
+// skipped.
+func (c *CLI) LabelAllCreated(labelKey, labelValue string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{labelKey: labelValue},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	dynamicClient := c.AdminDynamicClient()
+	var errs []error
+	for _, resource := range c.resourcesToDelete {
+		_, err := dynamicClient.Resource(resource.Resource).Namespace(resource.Namespace).Patch(context.Background(), resource.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("labeling %s %s/%s: %w", resource.Resource.Resource, resource.Namespace, resource.Name, err))
+		}
+	}
+	return kutilerrors.NewAggregate(errs)
+}
+
+// DeleteByLabel deletes the objects of the resource matching the selector in the namespace, or
+// cluster wide for cluster scoped resources when namespace is empty. Resources that do not support
+// deleting collections are deleted one by one.
+func (c *CLI) DeleteByLabel(gvr schema.GroupVersionResource, namespace string, selector labels.Selector) error {
+	ctx := context.Background()
+	client := c.AdminDynamicClient().Resource(gvr).Namespace(namespace)
+	listOptions := metav1.ListOptions{LabelSelector: selector.String()}
+
+	err := client.DeleteCollection(ctx, metav1.DeleteOptions{}, listOptions)
+	if !apierrors.IsMethodNotSupported(err) {
+		return err
+	}
+	list, err := client.List(ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, item := range list.Items {
+		err := client.Delete(ctx, item.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return kutilerrors.NewAggregate(errs)
+}
+
 func (c *CLI) CreateUser(prefix string) *userv1.User {
 	user, err := c.AdminUserClient().UserV1().Users().Create(context.Background(), &userv1.User{
 		ObjectMeta: metav1.ObjectMeta{GenerateName: prefix + c.Namespace()},
@@ -1039,6 +1526,102 @@ func (c *CLI) CreateUser(prefix string) *userv1.User {
 	return user
 }
 
+// CreateUserInGroups creates a user like CreateUser and adds it to each of the groups,
+// creating the groups that do not exist yet. The user and the groups created here are
+// deleted with the test namespace, and the user is removed from the groups that already
+// existed when the test ends.
+func (c *CLI) CreateUserInGroups(prefix string, groups ...string) (*userv1.User, error) {
+	ctx := context.Background()
+	groupClient := c.AdminUserClient().UserV1().Groups()
+
+	user := c.CreateUser(prefix)
+	for _, groupName := range groups {
+		added := false
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			group, err := groupClient.Get(ctx, groupName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				group, err = groupClient.Create(ctx, &userv1.Group{
+					ObjectMeta: metav1.ObjectMeta{Name: groupName},
+					Users:      userv1.OptionalNames{user.Name},
+				}, metav1.CreateOptions{})
+				if apierrors.IsAlreadyExists(err) {
+					// lost a race with a concurrent creation, retry as an update
+					return apierrors.NewConflict(userv1.Resource("groups"), groupName, err)
+				}
+				if err == nil {
+					c.AddResourceToDelete(userv1.GroupVersion.WithResource("groups"), group)
+				}
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			for _, name := range group.Users {
+				if name == user.Name {
+					return nil
+				}
+			}
+			group.Users = append(group.Users, user.Name)
+			_, err = groupClient.Update(ctx, group, metav1.UpdateOptions{})
+			added = err == nil
+			return err
+		})
+		if added {
+			g.DeferCleanup(removeUserFromGroup, groupClient, groupName, user.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to add user %q to group %q: %w", user.Name, groupName, err)
+		}
+	}
+	return user, nil
+}
+
+// removeUserFromGroup removes the user from the group, if both still exist.
+func removeUserFromGroup(groupClient userv1typedclient.GroupInterface, groupName, userName string) error {
+	ctx := context.Background()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		group, err := groupClient.Get(ctx, groupName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		users := make(userv1.OptionalNames, 0, len(group.Users))
+		for _, name := range group.Users {
+			if name != userName {
+				users = append(users, name)
+			}
+		}
+		if len(users) == len(group.Users) {
+			return nil
+		}
+		group.Users = users
+		_, err = groupClient.Update(ctx, group, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// CreateConfigMap creates a ConfigMap holding data in the current namespace. The ConfigMap is
+// deleted when the test ends.
+func (c *CLI) CreateConfigMap(name string, data map[string]string) (*corev1.ConfigMap, error) {
+	cm, err := c.KubeClient().CoreV1().ConfigMaps(c.Namespace()).Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Data:       data,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	c.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("configmaps"), cm)
+	return cm, nil
+}
+
+// CreateSecret creates a Secret of the given type holding data in the current namespace, see
+// CreateSecret. The Secret is deleted when the test ends.
+func (c *CLI) CreateSecret(name string, data map[string][]byte, typ corev1.SecretType) (*corev1.Secret, error) {
+	return CreateSecret(c, name, data, typ)
+}
+
 func (c *CLI) GetClientConfigForUser(username string) *rest.Config {
 
 	userAPIExists, err := DoesApiResourceExist(c.AdminConfig(), "users", "user.openshift.io")
@@ -1152,8 +1735,8 @@ func (c *CLI) WaitForAccessDenied(review *kubeauthorizationv1.SelfSubjectAccessR
 }
 
 func WaitForAccess(c kubernetes.Interface, allowed bool, review *kubeauthorizationv1.SelfSubjectAccessReview) error {
-	return wait.Poll(time.Second, time.Minute, func() (bool, error) {
-		response, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(), review, metav1.CreateOptions{})
+	return pollUntilTimeout(context.Background(), time.Second, time.Minute, false, func(ctx context.Context) (bool, error) {
+		response, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
 		if err != nil {
 			return false, err
 		}
@@ -1162,15 +1745,24 @@ func WaitForAccess(c kubernetes.Interface, allowed bool, review *kubeauthorizati
 }
 
 func GetClientConfig(kubeConfigFile string) (*rest.Config, error) {
+	return getClientConfig(kubeConfigFile, "")
+}
+
+// getClientConfig builds the rest config for the named context of the kubeconfig file,
+// or for its current context if the name is empty.
+func getClientConfig(kubeConfigFile, contextName string) (*rest.Config, error) {
 	kubeConfigBytes, err := ioutil.ReadFile(kubeConfigFile)
 	if err != nil {
 		return nil, err
 	}
-	kubeConfig, err := clientcmd.NewClientConfigFromBytes(kubeConfigBytes)
+	rawConfig, err := clientcmd.Load(kubeConfigBytes)
 	if err != nil {
 		return nil, err
 	}
-	clientConfig, err := kubeConfig.ClientConfig()
+	if len(contextName) == 0 {
+		contextName = rawConfig.CurrentContext
+	}
+	clientConfig, err := clientcmd.NewNonInteractiveClientConfig(*rawConfig, contextName, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,158 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestTestArtifactDirName(t *testing.T) {
+	tests := []struct {
+		testName string
+		want     string
+	}{
+		{
+			testName: "[sig-network][Feature:Router] The HAProxy router should serve routes [apigroup:route.openshift.io]",
+			want:     "sig-network-Feature-Router-The-HAProxy-router-should-serve-routes-apigroup-route.openshift.io",
+		},
+		{
+			testName: "",
+			want:     "unknown-test",
+		},
+		{
+			testName: strings.Repeat("a", 300),
+			want:     strings.Repeat("a", 200),
+		},
+	}
+	for _, tt := range tests {
+		if got := testArtifactDirName(tt.testName); got != tt.want {
+			t.Errorf("testArtifactDirName(%q) = %q, want %q", tt.testName, got, tt.want)
+		}
+	}
+}
+
+func TestOutputsStreaming(t *testing.T) {
+	c := &CLI{
+		execPath:   "sh",
+		globalArgs: []string{"-c", "echo out1; echo err1 >&2; echo out2"},
+		stdin:      &bytes.Buffer{},
+	}
+	var mu sync.Mutex
+	var streamedOut, streamedErr []string
+	stdout, stderr, err := c.OutputsStreaming(context.Background(),
+		func(line string) { mu.Lock(); defer mu.Unlock(); streamedOut = append(streamedOut, line) },
+		func(line string) { mu.Lock(); defer mu.Unlock(); streamedErr = append(streamedErr, line) },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "out1\nout2" || stderr != "err1" {
+		t.Errorf("unexpected outputs %q and %q", stdout, stderr)
+	}
+	if !reflect.DeepEqual(streamedOut, []string{"out1", "out2"}) || !reflect.DeepEqual(streamedErr, []string{"err1"}) {
+		t.Errorf("unexpected streamed lines %q and %q", streamedOut, streamedErr)
+	}
+}
+
+func TestStreamLines(t *testing.T) {
+	c := &CLI{execPath: "sh", globalArgs: []string{"-c", "echo line1; echo line2; echo line3"}, stdin: &bytes.Buffer{}}
+	var lines []string
+	err := c.streamLines(context.Background(), func(line string) bool {
+		lines = append(lines, line)
+		return true
+	})
+	if err != nil || !reflect.DeepEqual(lines, []string{"line1", "line2", "line3"}) {
+		t.Errorf("unexpected lines %q and error %v", lines, err)
+	}
+
+	// stopping early kills a command that would otherwise never exit
+	endless := &CLI{execPath: "sh", globalArgs: []string{"-c", "while true; do echo line; done"}, stdin: &bytes.Buffer{}}
+	count := 0
+	err = endless.streamLines(context.Background(), func(string) bool {
+		count++
+		return count < 3
+	})
+	if err != nil || count != 3 {
+		t.Errorf("expected to stop after 3 lines without error, got %d lines and %v", count, err)
+	}
+
+	failing := &CLI{execPath: "sh", globalArgs: []string{"-c", "echo denied >&2; exit 1"}, stdin: &bytes.Buffer{}}
+	if err := failing.streamLines(context.Background(), func(string) bool { return true }); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("expected an error with the stderr of the command, got %v", err)
+	}
+}
+
+func TestInputReader(t *testing.T) {
+	c := &CLI{execPath: "sh", globalArgs: []string{"-c", "wc -c"}, stdin: &bytes.Buffer{}}
+	c.InputString("ignored")
+	out, err := c.InputReader(strings.NewReader(strings.Repeat("x", 1<<20))).Output()
+	if err != nil || strings.TrimSpace(out) != "1048576" {
+		t.Errorf("expected the 1MiB reader on stdin, got %q and %v", out, err)
+	}
+}
+
+func TestExpectError(t *testing.T) {
+	failing := &CLI{execPath: "sh", globalArgs: []string{"-c", "echo denied >&2; exit 1"}, stdin: &bytes.Buffer{}}
+	stderr, err := failing.ExpectError()
+	if err != nil || stderr != "denied" {
+		t.Errorf("expected stderr %q and no error, got %q and %v", "denied", stderr, err)
+	}
+
+	succeeding := &CLI{execPath: "sh", globalArgs: []string{"-c", "echo ok"}, stdin: &bytes.Buffer{}}
+	if _, err := succeeding.ExpectError(); err == nil {
+		t.Errorf("expected an error for a command that succeeded")
+	}
+}
+
+func TestOverrideServer(t *testing.T) {
+	c := (&CLI{}).WithServerURL("https://10.0.0.5:6443")
+	config := c.overrideServer(&rest.Config{Host: "https://api.cluster.example.com:6443"})
+	if config.Host != "https://10.0.0.5:6443" || config.TLSClientConfig.ServerName != "api.cluster.example.com" {
+		t.Errorf("unexpected host %q and server name %q", config.Host, config.TLSClientConfig.ServerName)
+	}
+
+	config = c.overrideServer(&rest.Config{Host: "api.cluster.example.com:6443", TLSClientConfig: rest.TLSClientConfig{ServerName: "kubernetes"}})
+	if config.TLSClientConfig.ServerName != "kubernetes" {
+		t.Errorf("expected an explicit server name to be kept, got %q", config.TLSClientConfig.ServerName)
+	}
+
+	config = (&CLI{}).overrideServer(&rest.Config{Host: "https://api.cluster.example.com:6443"})
+	if config.Host != "https://api.cluster.example.com:6443" || len(config.TLSClientConfig.ServerName) > 0 {
+		t.Errorf("expected the config to be left alone, got host %q and server name %q", config.Host, config.TLSClientConfig.ServerName)
+	}
+}
+
+func TestContextFor(t *testing.T) {
+	c := (&CLI{adminConfigPath: "/tmp/admin.kubeconfig"}).WithContext("guest")
+	if got := c.contextFor("/tmp/admin.kubeconfig"); got != "guest" {
+		t.Errorf("expected the context to apply to the admin kubeconfig, got %q", got)
+	}
+	if got := c.contextFor("/tmp/user.kubeconfig"); got != "" {
+		t.Errorf("expected no context for a user kubeconfig, got %q", got)
+	}
+}
+
+func TestSpecContextKillsCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CLI{
+		execPath:   "sh",
+		globalArgs: []string{"-c", "exec sleep 30"},
+		stdin:      &bytes.Buffer{},
+		specCtx:    ctx,
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, _, err := c.Outputs()
+	if err == nil || !strings.Contains(err.Error(), "killed as the spec ended") {
+		t.Errorf("expected the command to be killed with the spec, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("the command ran for %s after the spec ended", elapsed)
+	}
+}
@@ -0,0 +1,142 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	o "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// cloudCredentialModeAnnotation is set by the cloud-credential-operator on the root
+// credentials secret to the mode it runs in.
+const cloudCredentialModeAnnotation = "cloudcredential.openshift.io/mode"
+
+// rootCredentialsSecrets are the root cloud credentials secrets in kube-system per platform.
+var rootCredentialsSecrets = map[configv1.PlatformType]string{
+	configv1.AWSPlatformType:       "aws-creds",
+	configv1.AzurePlatformType:     "azure-credentials",
+	configv1.GCPPlatformType:       "gcp-credentials",
+	configv1.OpenStackPlatformType: "openstack-credentials",
+	configv1.OvirtPlatformType:     "ovirt-credentials",
+	configv1.VSpherePlatformType:   "vsphere-creds",
+}
+
+// CloudCredentialMode returns the mode the cloud-credential-operator runs in. If the
+// CloudCredential config leaves the mode to the platform default, the effective mode is
+// inferred from the root credentials secret: Manual if there is none, otherwise the mode
+// the operator annotated the secret with. An empty mode is returned for platforms without
+// root credentials.
+func CloudCredentialMode(oc *CLI) (operatorv1.CloudCredentialsMode, error) {
+	ctx := context.Background()
+	cloudCredential, err := oc.AdminOperatorClient().OperatorV1().CloudCredentials().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failure getting the CloudCredential config: %w", err)
+	}
+	if cloudCredential.Spec.CredentialsMode != operatorv1.CloudCredentialsModeDefault {
+		return cloudCredential.Spec.CredentialsMode, nil
+	}
+
+	infra, err := oc.AdminConfigClient().ConfigV1().Infrastructures().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failure getting test cluster Infrastructure: %w", err)
+	}
+	if infra.Status.PlatformStatus == nil {
+		return operatorv1.CloudCredentialsModeDefault, nil
+	}
+	secretName, ok := rootCredentialsSecrets[infra.Status.PlatformStatus.Type]
+	if !ok {
+		return operatorv1.CloudCredentialsModeDefault, nil
+	}
+	secret, err := oc.AdminKubeClient().CoreV1().Secrets("kube-system").Get(ctx, secretName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		secret = nil
+	} else if err != nil {
+		return "", fmt.Errorf("failure getting the root credentials secret kube-system/%s: %w", secretName, err)
+	}
+	return effectiveCloudCredentialMode(secret), nil
+}
+
+// effectiveCloudCredentialMode infers the mode of the default configuration from the root credentials secret.
+func effectiveCloudCredentialMode(rootSecret *corev1.Secret) operatorv1.CloudCredentialsMode {
+	if rootSecret == nil {
+		return operatorv1.CloudCredentialsModeManual
+	}
+	switch rootSecret.Annotations[cloudCredentialModeAnnotation] {
+	case "mint":
+		return operatorv1.CloudCredentialsModeMint
+	case "passthrough":
+		return operatorv1.CloudCredentialsModePassthrough
+	default:
+		// the operator has not annotated the secret yet, or the credentials are insufficient for minting
+		return operatorv1.CloudCredentialsModeDefault
+	}
+}
+
+// IsSTSCluster returns true if the cluster uses short-lived cloud credentials: AWS STS,
+// Azure Workload Identity or GCP Workload Identity Federation. These clusters run the
+// cloud-credential-operator in Manual mode with a custom service account issuer.
+func IsSTSCluster(oc *CLI) bool {
+	mode, err := CloudCredentialMode(oc)
+	if err != nil || mode != operatorv1.CloudCredentialsModeManual {
+		return false
+	}
+	infra, err := oc.AdminConfigClient().ConfigV1().Infrastructures().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil || infra.Status.PlatformStatus == nil {
+		return false
+	}
+	switch infra.Status.PlatformStatus.Type {
+	case configv1.AWSPlatformType, configv1.AzurePlatformType, configv1.GCPPlatformType:
+	default:
+		return false
+	}
+	authentication, err := oc.AdminConfigClient().ConfigV1().Authentications().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	return len(authentication.Spec.ServiceAccountIssuer) > 0
+}
+
+// SkipUnlessCloudCredentialMode skips the test unless the cloud-credential-operator runs in one of the modes.
+func SkipUnlessCloudCredentialMode(oc *CLI, modes ...operatorv1.CloudCredentialsMode) {
+	mode, err := CloudCredentialMode(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the cloud credential mode")
+	for _, m := range modes {
+		if m == mode {
+			return
+		}
+	}
+	skipper.Skipf("Cloud credential mode %q is not one of %v", mode, modes)
+}
+
+// SkipIfCloudCredentialMode skips the test if the cloud-credential-operator runs in one of the modes.
+func SkipIfCloudCredentialMode(oc *CLI, modes ...operatorv1.CloudCredentialsMode) {
+	mode, err := CloudCredentialMode(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the cloud credential mode")
+	for _, m := range modes {
+		if m == mode {
+			skipper.Skipf("Skipping in cloud credential mode %q", mode)
+		}
+	}
+}
+
+// SkipIfSTS skips the test if the cluster uses short-lived cloud credentials.
+func (c *CLI) SkipIfSTS(reason string) {
+	if IsSTSCluster(c) {
+		skipper.Skipf("Skipping on a cluster with short-lived cloud credentials: %s", reason)
+	}
+}
+
+// SkipUnlessSTS skips the test unless the cluster uses short-lived cloud credentials.
+func (c *CLI) SkipUnlessSTS(reason string) {
+	if !IsSTSCluster(c) {
+		skipper.Skipf("Skipping on a cluster without short-lived cloud credentials: %s", reason)
+	}
+}
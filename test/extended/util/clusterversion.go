@@ -4,12 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
+
+	o "github.com/onsi/gomega"
+
+	"github.com/blang/semver/v4"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/kubernetes/test/e2e/framework"
 	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
 
 	configv1 "github.com/openshift/api/config/v1"
 	configv1client "github.com/openshift/client-go/config/clientset/versioned"
@@ -37,16 +43,128 @@ func GetCurrentVersion(ctx context.Context, config *restclient.Config) (string,
 	if err != nil {
 		return "", err
 	}
-	for _, h := range cv.Status.History {
+	return currentVersionFromHistory(cv.Status.History), nil
+}
+
+func currentVersionFromHistory(history []configv1.UpdateHistory) string {
+	for _, h := range history {
 		if h.State == configv1.CompletedUpdate {
-			return h.Version, nil
+			return h.Version
 		}
 	}
 	// Empty history should only occur if method is called early in startup before history is populated.
-	if len(cv.Status.History) != 0 {
-		return cv.Status.History[len(cv.Status.History)-1].Version, nil
+	if len(history) != 0 {
+		return history[len(history)-1].Version
+	}
+	return ""
+}
+
+// IsClusterUpgrading returns true if the ClusterVersion reports Progressing=True.
+func IsClusterUpgrading(oc *CLI) (bool, error) {
+	cv, err := oc.AdminConfigClient().ConfigV1().ClusterVersions().Get(context.Background(), "version", metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	return clusterVersionIsProgressing(cv), nil
+}
+
+// CurrentClusterVersion returns the parsed version the cluster has completely
+// rolled out. Partial history entries, left by an upgrade that is in progress or
+// that did not complete, are skipped in favor of the last completed version.
+// If no update has completed yet, the originally installed version is used.
+// The version is determined by GetCurrentVersion.
+func CurrentClusterVersion(oc *CLI) (semver.Version, error) {
+	version, err := GetCurrentVersion(context.Background(), oc.AdminConfig())
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return parseCurrentVersion(version)
+}
+
+// SkipIfUpgrading skips the test while the cluster is upgrading.
+func (c *CLI) SkipIfUpgrading(reason string) {
+	upgrading, err := IsClusterUpgrading(c)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the cluster is upgrading")
+	if upgrading {
+		skipper.Skipf("Skipping while the cluster is upgrading: %s", reason)
+	}
+}
+
+func clusterVersionIsProgressing(cv *configv1.ClusterVersion) bool {
+	for _, condition := range cv.Status.Conditions {
+		if condition.Type == configv1.OperatorProgressing {
+			return condition.Status == configv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func parseCurrentVersion(version string) (semver.Version, error) {
+	if len(version) == 0 {
+		return semver.Version{}, fmt.Errorf("unable to determine the cluster version, the ClusterVersion has no update history yet")
+	}
+	parsed, err := semver.Parse(version)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("unable to parse cluster version %q: %w", version, err)
+	}
+	return parsed, nil
+}
+
+// ClusterVersionStatus returns the status of the ClusterVersion, including its update history
+// with the most recent update first.
+func (c *CLI) ClusterVersionStatus() (*configv1.ClusterVersionStatus, error) {
+	cv, err := c.AdminConfigClient().ConfigV1().ClusterVersions().Get(context.Background(), "version", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &cv.Status, nil
+}
+
+// CurrentVersion returns the version the cluster has completely rolled out, as described for
+// GetCurrentVersion.
+func (c *CLI) CurrentVersion() (string, error) {
+	return GetCurrentVersion(context.Background(), c.AdminConfig())
+}
+
+// DesiredVersion returns the version the cluster is updating to, which is the current version
+// when no update is in progress.
+func (c *CLI) DesiredVersion() (string, error) {
+	status, err := c.ClusterVersionStatus()
+	if err != nil {
+		return "", err
+	}
+	return status.Desired.Version, nil
+}
+
+// WaitForClusterVersionCondition waits for the ClusterVersion condition of type cond to have the
+// status. On timeout the returned error includes the last observed condition.
+func (c *CLI) WaitForClusterVersionCondition(cond configv1.ClusterStatusConditionType, status configv1.ConditionStatus, timeout time.Duration) error {
+	var last *configv1.ClusterOperatorStatusCondition
+	err := pollUntilTimeout(context.Background(), 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		cv, err := c.AdminConfigClient().ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+		if err != nil {
+			framework.Logf("Unable to get the ClusterVersion: %v", err)
+			return false, nil
+		}
+		last = findClusterVersionCondition(cv.Status.Conditions, cond)
+		return last != nil && last.Status == status, nil
+	})
+	if err == nil {
+		return nil
+	}
+	if last == nil {
+		return fmt.Errorf("waiting for ClusterVersion condition %s=%s: %w (condition not reported)", cond, status, err)
+	}
+	return fmt.Errorf("waiting for ClusterVersion condition %s=%s: %w, last observed %s=%s: %s: %s", cond, status, err, cond, last.Status, last.Reason, last.Message)
+}
+
+func findClusterVersionCondition(conditions []configv1.ClusterOperatorStatusCondition, cond configv1.ClusterStatusConditionType) *configv1.ClusterOperatorStatusCondition {
+	for i := range conditions {
+		if conditions[i].Type == cond {
+			return &conditions[i]
+		}
 	}
-	return "", nil
+	return nil
 }
 
 // GetReleaseImage returns ReleaseImage.
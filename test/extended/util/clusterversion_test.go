@@ -0,0 +1,126 @@
+package util
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestCurrentVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          configv1.ClusterVersionStatus
+		expectedVersion string
+		expectedFailure bool
+	}{
+		{
+			name: "completed install",
+			status: configv1.ClusterVersionStatus{
+				History: []configv1.UpdateHistory{
+					{State: configv1.CompletedUpdate, Version: "4.16.3"},
+				},
+			},
+			expectedVersion: "4.16.3",
+		},
+		{
+			name: "upgrade in progress",
+			status: configv1.ClusterVersionStatus{
+				Desired: configv1.Release{Version: "4.17.0"},
+				History: []configv1.UpdateHistory{
+					{State: configv1.PartialUpdate, Version: "4.17.0"},
+					{State: configv1.CompletedUpdate, Version: "4.16.3"},
+				},
+			},
+			expectedVersion: "4.16.3",
+		},
+		{
+			name: "partial upgrade followed by another upgrade",
+			status: configv1.ClusterVersionStatus{
+				History: []configv1.UpdateHistory{
+					{State: configv1.PartialUpdate, Version: "4.17.1"},
+					{State: configv1.PartialUpdate, Version: "4.17.0"},
+					{State: configv1.CompletedUpdate, Version: "4.16.3"},
+				},
+			},
+			expectedVersion: "4.16.3",
+		},
+		{
+			name: "install in progress",
+			status: configv1.ClusterVersionStatus{
+				History: []configv1.UpdateHistory{
+					{State: configv1.PartialUpdate, Version: "4.17.0-0.nightly-2024-09-01-120000"},
+				},
+			},
+			expectedVersion: "4.17.0-0.nightly-2024-09-01-120000",
+		},
+		{
+			name: "empty history",
+			status: configv1.ClusterVersionStatus{
+				Desired: configv1.Release{Version: "4.17.0"},
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "invalid version",
+			status: configv1.ClusterVersionStatus{
+				History: []configv1.UpdateHistory{
+					{State: configv1.CompletedUpdate, Version: "not-a-version"},
+				},
+			},
+			expectedFailure: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, err := parseCurrentVersion(currentVersionFromHistory(test.status.History))
+			if test.expectedFailure {
+				if err == nil {
+					t.Fatalf("expected an error, got version %v", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if version.String() != test.expectedVersion {
+				t.Errorf("expected version %q, got %q", test.expectedVersion, version.String())
+			}
+		})
+	}
+}
+
+func TestClusterVersionIsProgressing(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []configv1.ClusterOperatorStatusCondition
+		expected   bool
+	}{
+		{
+			name: "progressing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue},
+			},
+			expected: true,
+		},
+		{
+			name: "not progressing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse},
+			},
+		},
+		{
+			name: "no conditions",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cv := &configv1.ClusterVersion{Status: configv1.ClusterVersionStatus{Conditions: test.conditions}}
+			if actual := clusterVersionIsProgressing(cv); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
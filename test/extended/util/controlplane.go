@@ -0,0 +1,103 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	o "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+)
+
+const (
+	masterNodeRoleLabel       = "node-role.kubernetes.io/master"
+	controlPlaneNodeRoleLabel = "node-role.kubernetes.io/control-plane"
+	workerNodeRoleLabel       = "node-role.kubernetes.io/worker"
+)
+
+// controlPlaneLayout describes the control-plane nodes of the cluster.
+type controlPlaneLayout struct {
+	controlPlaneNodes int
+	// compact is true if all nodes are schedulable control-plane nodes
+	compact bool
+}
+
+// ControlPlaneNodeCount returns the number of control-plane nodes of the cluster.
+func ControlPlaneNodeCount(oc *CLI) (int, error) {
+	layout, err := getControlPlaneLayout(oc.AdminKubeClient())
+	if err != nil {
+		return 0, err
+	}
+	return layout.controlPlaneNodes, nil
+}
+
+// IsCompactCluster returns true if the cluster only has control-plane nodes that also
+// carry the worker role, e.g. a three node cluster with schedulable masters.
+func IsCompactCluster(oc *CLI) (bool, error) {
+	layout, err := getControlPlaneLayout(oc.AdminKubeClient())
+	if err != nil {
+		return false, err
+	}
+	return layout.compact, nil
+}
+
+// EtcdMemberCount returns the number of etcd members listed in the etcd-endpoints
+// configmap maintained by the etcd operator.
+func EtcdMemberCount(oc *CLI) (int, error) {
+	return getEtcdMemberCount(oc.AdminKubeClient())
+}
+
+// SkipUnlessHighlyAvailable skips the test unless the cluster has at least three
+// control-plane nodes and three etcd members.
+func SkipUnlessHighlyAvailable(oc *CLI) {
+	controlPlaneNodes, err := ControlPlaneNodeCount(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the number of control-plane nodes")
+	etcdMembers, err := EtcdMemberCount(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the number of etcd members")
+	if controlPlaneNodes < 3 || etcdMembers < 3 {
+		skipper.Skipf("Skipping because the cluster is not highly available (%d control-plane nodes, %d etcd members)", controlPlaneNodes, etcdMembers)
+	}
+}
+
+func getControlPlaneLayout(kubeClient kubernetes.Interface) (*controlPlaneLayout, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return controlPlaneLayoutFromNodes(nodes.Items), nil
+}
+
+func controlPlaneLayoutFromNodes(nodes []corev1.Node) *controlPlaneLayout {
+	layout := &controlPlaneLayout{}
+	schedulableControlPlaneNodes := 0
+	for _, node := range nodes {
+		_, master := node.Labels[masterNodeRoleLabel]
+		_, controlPlane := node.Labels[controlPlaneNodeRoleLabel]
+		if !master && !controlPlane {
+			continue
+		}
+		layout.controlPlaneNodes++
+		if _, worker := node.Labels[workerNodeRoleLabel]; worker {
+			schedulableControlPlaneNodes++
+		}
+	}
+	layout.compact = layout.controlPlaneNodes > 1 &&
+		schedulableControlPlaneNodes == layout.controlPlaneNodes &&
+		layout.controlPlaneNodes == len(nodes)
+	return layout
+}
+
+func getEtcdMemberCount(kubeClient kubernetes.Interface) (int, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps("openshift-etcd").Get(context.Background(), "etcd-endpoints", metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to read the etcd members: %w", err)
+	}
+	// each entry maps a member ID to its IP
+	if len(cm.Data) == 0 {
+		return 0, fmt.Errorf("no etcd members found in openshift-etcd/etcd-endpoints")
+	}
+	return len(cm.Data), nil
+}
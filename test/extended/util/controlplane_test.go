@@ -0,0 +1,133 @@
+package util
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestControlPlaneLayout(t *testing.T) {
+	tests := []struct {
+		name                 string
+		nodes                []runtime.Object
+		expectedControlPlane int
+		expectedCompact      bool
+	}{
+		{
+			name: "highly available with workers",
+			nodes: []runtime.Object{
+				testNode("master-0", "master", "control-plane"),
+				testNode("master-1", "master", "control-plane"),
+				testNode("master-2", "master", "control-plane"),
+				testNode("worker-0", "worker"),
+			},
+			expectedControlPlane: 3,
+		},
+		{
+			name: "compact",
+			nodes: []runtime.Object{
+				testNode("master-0", "master", "worker"),
+				testNode("master-1", "master", "worker"),
+				testNode("master-2", "master", "worker"),
+			},
+			expectedControlPlane: 3,
+			expectedCompact:      true,
+		},
+		{
+			name: "schedulable masters with additional workers",
+			nodes: []runtime.Object{
+				testNode("master-0", "control-plane", "worker"),
+				testNode("master-1", "control-plane", "worker"),
+				testNode("master-2", "control-plane", "worker"),
+				testNode("worker-0", "worker"),
+			},
+			expectedControlPlane: 3,
+		},
+		{
+			name: "single node",
+			nodes: []runtime.Object{
+				testNode("master-0", "master", "worker"),
+			},
+			expectedControlPlane: 1,
+		},
+		{
+			name: "external control plane",
+			nodes: []runtime.Object{
+				testNode("worker-0", "worker"),
+				testNode("worker-1", "worker"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.nodes...)
+			layout, err := getControlPlaneLayout(client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if layout.controlPlaneNodes != test.expectedControlPlane {
+				t.Errorf("expected %d control-plane nodes, got %d", test.expectedControlPlane, layout.controlPlaneNodes)
+			}
+			if layout.compact != test.expectedCompact {
+				t.Errorf("expected compact %v, got %v", test.expectedCompact, layout.compact)
+			}
+		})
+	}
+}
+
+func TestEtcdMemberCount(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         []runtime.Object
+		expected        int
+		expectedFailure bool
+	}{
+		{
+			name: "three members",
+			objects: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: "etcd-endpoints"},
+					Data: map[string]string{
+						"6c2d8d3b0b3e5a1f": "10.0.0.1",
+						"8e0f4c5b3a2d1e7c": "10.0.0.2",
+						"a1b2c3d4e5f60718": "10.0.0.3",
+					},
+				},
+			},
+			expected: 3,
+		},
+		{
+			name: "empty member list",
+			objects: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: "etcd-endpoints"}},
+			},
+			expectedFailure: true,
+		},
+		{
+			name:            "no etcd operator",
+			expectedFailure: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := getEtcdMemberCount(fake.NewSimpleClientset(test.objects...))
+			if test.expectedFailure {
+				if err == nil {
+					t.Fatalf("expected an error, got %d members", count)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != test.expected {
+				t.Errorf("expected %d members, got %d", test.expected, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package util
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// smallFileCopyLimit is the largest file copied with base64 to or from pods without tar.
+const smallFileCopyLimit = 10 * 1024 * 1024
+
+// fileDigests maps the slash separated path of each regular file, relative to the copied
+// root, to its sha256. A copied regular file is recorded under the empty path.
+type fileDigests map[string]string
+
+// CopyFileToPod copies the local file or directory to remotePath in the container of the
+// pod by streaming a tar archive to the exec of tar, then verifies the sha256 of every
+// copied file. Single files up to 10MiB are copied with base64 to pods without tar.
+func CopyFileToPod(oc *CLI, ns, pod, container, localPath, remotePath string) error {
+	ctx := context.Background()
+	hasTar, err := podHasCommand(ctx, oc, ns, pod, container, "tar")
+	if err != nil {
+		return err
+	}
+
+	var expected fileDigests
+	if hasTar {
+		expected, err = copyTarToPod(ctx, oc, ns, pod, container, localPath, remotePath)
+	} else {
+		expected, err = copySmallFileToPod(ctx, oc, ns, pod, container, localPath, remotePath)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to copy %s to %s/%s:%s: %w", localPath, ns, pod, remotePath, err)
+	}
+	remote, err := remoteDigests(ctx, oc, ns, pod, container, remotePath)
+	if err != nil {
+		return err
+	}
+	// other files may already exist in a remote directory
+	if err := compareDigests(expected, remote); err != nil {
+		return err
+	}
+	e2e.Logf("Copied %s to %s/%s:%s (%d files)", localPath, ns, pod, remotePath, len(expected))
+	return nil
+}
+
+// CopyFileFromPod copies the file or directory at remotePath in the container of the pod to
+// localPath by streaming a tar archive from the exec of tar, then verifies the sha256 of
+// every copied file. Single files up to 10MiB are copied with base64 from pods without tar.
+func CopyFileFromPod(oc *CLI, ns, pod, container, remotePath, localPath string) error {
+	ctx := context.Background()
+	hasTar, err := podHasCommand(ctx, oc, ns, pod, container, "tar")
+	if err != nil {
+		return err
+	}
+
+	var actual fileDigests
+	if hasTar {
+		actual, err = copyTarFromPod(ctx, oc, ns, pod, container, remotePath, localPath)
+	} else {
+		actual, err = copySmallFileFromPod(ctx, oc, ns, pod, container, remotePath, localPath)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to copy %s/%s:%s to %s: %w", ns, pod, remotePath, localPath, err)
+	}
+	remote, err := remoteDigests(ctx, oc, ns, pod, container, remotePath)
+	if err != nil {
+		return err
+	}
+	if err := compareDigests(remote, actual); err != nil {
+		return err
+	}
+	e2e.Logf("Copied %s/%s:%s to %s (%d files)", ns, pod, remotePath, localPath, len(actual))
+	return nil
+}
+
+func copyTarToPod(ctx context.Context, oc *CLI, ns, pod, container, localPath, remotePath string) (fileDigests, error) {
+	reader, writer := io.Pipe()
+	type result struct {
+		digests fileDigests
+		err     error
+	}
+	written := make(chan result, 1)
+	go func() {
+		digests, err := writeTar(localPath, path.Base(remotePath), writer)
+		writer.CloseWithError(err)
+		written <- result{digests, err}
+	}()
+
+	_, execErr := execInPodStreaming(ctx, oc, ns, pod, container, reader, io.Discard, "tar", "-xmf", "-", "-C", path.Dir(remotePath))
+	// unblock the writer if tar exited early
+	reader.CloseWithError(io.ErrClosedPipe)
+	res := <-written
+	if res.err != nil {
+		return nil, res.err
+	}
+	return res.digests, execErr
+}
+
+func copyTarFromPod(ctx context.Context, oc *CLI, ns, pod, container, remotePath, localPath string) (fileDigests, error) {
+	reader, writer := io.Pipe()
+	type result struct {
+		digests fileDigests
+		err     error
+	}
+	extracted := make(chan result, 1)
+	go func() {
+		digests, err := extractTar(reader, localPath)
+		// drain what is left so that the exec is not blocked
+		io.Copy(io.Discard, reader)
+		extracted <- result{digests, err}
+	}()
+
+	_, execErr := execInPodStreaming(ctx, oc, ns, pod, container, nil, writer, "tar", "-cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath))
+	writer.CloseWithError(execErr)
+	res := <-extracted
+	if execErr != nil {
+		return nil, execErr
+	}
+	return res.digests, res.err
+}
+
+func copySmallFileToPod(ctx context.Context, oc *CLI, ns, pod, container, localPath, remotePath string) (fileDigests, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || info.Size() > smallFileCopyLimit {
+		return nil, fmt.Errorf("tar is not available in the container, only regular files up to %d bytes can be copied", smallFileCopyLimit)
+	}
+
+	reader, writer := io.Pipe()
+	hash := sha256.New()
+	go func() {
+		encoder := base64.NewEncoder(base64.StdEncoding, writer)
+		_, err := io.Copy(encoder, io.TeeReader(f, hash))
+		if err == nil {
+			err = encoder.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	_, execErr := execInPodStreaming(ctx, oc, ns, pod, container, reader, io.Discard, "sh", "-c", `base64 -d > "$1"`, "--", remotePath)
+	reader.CloseWithError(io.ErrClosedPipe)
+	if execErr != nil {
+		return nil, execErr
+	}
+	return fileDigests{"": hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func copySmallFileFromPod(ctx context.Context, oc *CLI, ns, pod, container, remotePath, localPath string) (fileDigests, error) {
+	f, err := os.Create(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader, writer := io.Pipe()
+	hash := sha256.New()
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.MultiWriter(f, hash), base64.NewDecoder(base64.StdEncoding, reader))
+		io.Copy(io.Discard, reader)
+		copied <- err
+	}()
+	_, execErr := execInPodStreaming(ctx, oc, ns, pod, container, nil, writer, "base64", remotePath)
+	writer.CloseWithError(execErr)
+	if err := <-copied; err != nil && execErr == nil {
+		return nil, err
+	}
+	if execErr != nil {
+		return nil, execErr
+	}
+	return fileDigests{"": hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// podHasCommand returns true if the command can be found in the container.
+func podHasCommand(ctx context.Context, oc *CLI, ns, pod, container, command string) (bool, error) {
+	_, _, err := ExecInPod(ctx, oc, ns, pod, container, "sh", "-c", `command -v "$1"`, "--", command)
+	var execErr *ExecError
+	if errors.As(err, &execErr) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// remoteDigests returns the sha256 of the files under remotePath in the container.
+func remoteDigests(ctx context.Context, oc *CLI, ns, pod, container, remotePath string) (fileDigests, error) {
+	stdout, _, err := ExecInPod(ctx, oc, ns, pod, container, "find", remotePath, "-type", "f", "-exec", "sha256sum", "{}", "+")
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify the copied files: %w", err)
+	}
+	return parseSHA256Sums(stdout, remotePath)
+}
+
+// writeTar writes a tar archive of the local file or directory to w, naming its root
+// rootName, and returns the digests of the archived files. File contents are streamed.
+func writeTar(localPath, rootName string, w io.Writer) (fileDigests, error) {
+	digests := fileDigests{}
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(rootName, rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(tw, io.TeeReader(f, hash)); err != nil {
+			return err
+		}
+		digests[rel] = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return digests, tw.Close()
+}
+
+// extractTar extracts the tar archive read from r to localPath, replacing the name of the
+// archive root with localPath, and returns the digests of the extracted files.
+func extractTar(r io.Reader, localPath string) (fileDigests, error) {
+	digests := fileDigests{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return digests, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(header.Name)
+		rel := ""
+		if i := strings.Index(name, "/"); i >= 0 {
+			rel = name[i+1:]
+		}
+		if rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			return nil, fmt.Errorf("refusing to extract %q outside of %s", header.Name, localPath)
+		}
+		target := filepath.Join(localPath, filepath.FromSlash(rel))
+		if err := checkNoSymlinkInPath(localPath, rel); err != nil {
+			return nil, fmt.Errorf("refusing to extract %q: %w", header.Name, err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return nil, err
+			}
+		case tar.TypeSymlink:
+			link := path.Join(path.Dir(rel), header.Linkname)
+			if path.IsAbs(header.Linkname) || link == ".." || strings.HasPrefix(link, "../") {
+				return nil, fmt.Errorf("refusing to extract symlink %q to %q outside of %s", header.Name, header.Linkname, localPath)
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode)|0600)
+			if err != nil {
+				return nil, err
+			}
+			hash := sha256.New()
+			_, err = io.Copy(io.MultiWriter(f, hash), tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return nil, err
+			}
+			digests[rel] = hex.EncodeToString(hash.Sum(nil))
+		default:
+			e2e.Logf("Skipping %s of unsupported type %c", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// checkNoSymlinkInPath returns an error if rel, or one of its parent directories below
+// localPath, exists as a symlink, so that extracting to it cannot write through a link.
+func checkNoSymlinkInPath(localPath, rel string) error {
+	if len(rel) == 0 {
+		return nil
+	}
+	current := localPath
+	for _, component := range strings.Split(rel, "/") {
+		current = filepath.Join(current, component)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", current)
+		}
+	}
+	return nil
+}
+
+// parseSHA256Sums parses the output of sha256sum for the files under root.
+func parseSHA256Sums(output, root string) (fileDigests, error) {
+	root = path.Clean(root)
+	digests := fileDigests{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected sha256sum output %q", line)
+		}
+		file := path.Clean(strings.TrimLeft(fields[1], " *"))
+		rel, ok := "", file == root
+		if !ok {
+			rel, ok = strings.CutPrefix(file, strings.TrimSuffix(root, "/")+"/")
+		}
+		if !ok {
+			return nil, fmt.Errorf("sha256sum reported %q which is not under %s", file, root)
+		}
+		digests[rel] = fields[0]
+	}
+	return digests, scanner.Err()
+}
+
+// compareDigests returns an error listing the expected files that are missing or differ.
+func compareDigests(expected, actual fileDigests) error {
+	var mismatches []string
+	for file, digest := range expected {
+		switch actualDigest, ok := actual[file]; {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("%q is missing", file))
+		case actualDigest != digest:
+			mismatches = append(mismatches, fmt.Sprintf("%q has sha256 %s instead of %s", file, actualDigest, digest))
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("copied files do not match: %s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
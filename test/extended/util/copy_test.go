@@ -0,0 +1,212 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTarRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "small"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "nested", "other"), []byte("world\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	expected, actual := tarThroughPipe(t, src, dst)
+	if err := compareDigests(expected, actual); err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 2 {
+		t.Errorf("expected 2 files, got %v", actual)
+	}
+	content, err := os.ReadFile(filepath.Join(dst, "nested", "other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "world\n" {
+		t.Errorf("unexpected content %q", content)
+	}
+}
+
+func TestTarRoundTripLargeFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large file copy in short mode")
+	}
+	const size = 101 * 1024 * 1024
+	src := filepath.Join(t.TempDir(), "large")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.New()
+	block := make([]byte, 1024*1024)
+	for i := range block {
+		block[i] = byte(i % 251)
+	}
+	for written := 0; written < size; written += len(block) {
+		if _, err := io.MultiWriter(f, hash).Write(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	dst := filepath.Join(t.TempDir(), "copied")
+	expected, actual := tarThroughPipe(t, src, dst)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	// the whole file never goes through a single allocation
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/2 {
+		t.Errorf("expected the file to be streamed, %d bytes were allocated", allocated)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if expected[""] != sum || actual[""] != sum {
+		t.Errorf("expected sha256 %s, got %s written and %s extracted", sum, expected[""], actual[""])
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != size {
+		t.Errorf("expected %d bytes, got %d", size, info.Size())
+	}
+}
+
+func TestExtractTarRefusesEscapes(t *testing.T) {
+	type entry struct {
+		name, link string
+		typeflag   byte
+	}
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{
+			name:    "absolute symlink",
+			entries: []entry{{name: "root/link", link: "/etc", typeflag: tar.TypeSymlink}},
+		},
+		{
+			name:    "symlink to the parent",
+			entries: []entry{{name: "root/nested/link", link: "../../escaped", typeflag: tar.TypeSymlink}},
+		},
+		{
+			name: "file written through a symlink",
+			entries: []entry{
+				{name: "root/link", link: ".", typeflag: tar.TypeSymlink},
+				{name: "root/link/file", typeflag: tar.TypeReg},
+			},
+		},
+		{
+			name: "file replacing a symlink",
+			entries: []entry{
+				{name: "root/link", link: "other", typeflag: tar.TypeSymlink},
+				{name: "root/link", typeflag: tar.TypeReg},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, e := range test.entries {
+				if err := tw.WriteHeader(&tar.Header{Name: e.name, Linkname: e.link, Typeflag: e.typeflag, Mode: 0644}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dst := filepath.Join(t.TempDir(), "dst")
+			if err := os.MkdirAll(filepath.Join(dst, "nested"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			_, err := extractTar(&buf, dst)
+			if err == nil || !strings.Contains(err.Error(), "refusing") {
+				t.Errorf("expected the archive to be refused, got %v", err)
+			}
+		})
+	}
+}
+
+// tarThroughPipe streams src into dst the same way the pod copy helpers do.
+func tarThroughPipe(t *testing.T, src, dst string) (written, extracted fileDigests) {
+	reader, writer := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		written, err = writeTar(src, "root", writer)
+		writer.CloseWithError(err)
+		errCh <- err
+	}()
+	extracted, err := extractTar(reader, dst)
+	if err != nil {
+		t.Fatalf("unexpected extract error: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("unexpected archive error: %v", err)
+	}
+	return written, extracted
+}
+
+func TestParseSHA256Sums(t *testing.T) {
+	output := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  /data/copy/small\n" +
+		"e258d248fda94c63753607f7c4494ee0fcbe92f1a76bfdac795c9d84101eb317 */data/copy/nested/other\n"
+	digests, err := parseSHA256Sums(output, "/data/copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fileDigests{
+		"small":        "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"nested/other": "e258d248fda94c63753607f7c4494ee0fcbe92f1a76bfdac795c9d84101eb317",
+	}
+	if err := compareDigests(expected, digests); err != nil {
+		t.Error(err)
+	}
+
+	single, err := parseSHA256Sums("5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  /data/file\n", "/data/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := single[""]; !ok {
+		t.Errorf("expected a single file to be recorded under the empty path, got %v", single)
+	}
+
+	if _, err := parseSHA256Sums("5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  /data/copy-old/small\n", "/data/copy"); err == nil {
+		t.Errorf("expected a file outside of the root to be reported")
+	}
+
+	trailing, err := parseSHA256Sums("5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  /data/copy/small\n", "/data/copy/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := trailing["small"]; !ok {
+		t.Errorf("expected the file to be recorded relative to the root, got %v", trailing)
+	}
+
+	if err := compareDigests(expected, fileDigests{"small": "bad"}); err == nil {
+		t.Errorf("expected mismatching digests to be reported")
+	}
+}
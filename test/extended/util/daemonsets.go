@@ -0,0 +1,143 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// daemonSetTemplateGenerationLabel is set by the DaemonSet controller on the pods it creates.
+const daemonSetTemplateGenerationLabel = "pod-template-generation"
+
+// WaitForDaemonSetRollout waits for the pods of the DaemonSet to be updated and available on every
+// node it is scheduled to. A DaemonSet not scheduled to any node is rolled out once its status is
+// observed. On timeout the returned error lists the pods that are behind and their nodes.
+func WaitForDaemonSetRollout(oc *CLI, ns, name string, timeout time.Duration) error {
+	ds, status, err := waitForDaemonSet(oc, ns, name, timeout, daemonSetRolledOut)
+	if err != nil {
+		if ds == nil {
+			return fmt.Errorf("daemonset %s/%s did not roll out: %w (daemonset not observed)", ns, name, err)
+		}
+		return fmt.Errorf("daemonset %s/%s did not roll out: %w (%s; pods behind: %s)", ns, name, err, status,
+			describePodsBehind(oc, ns, ds.Spec.Selector, daemonSetPodUpToDate(ds)))
+	}
+	e2e.Logf("DaemonSet %s/%s rolled out", ns, name)
+	return nil
+}
+
+// WaitForDaemonSetReady waits for the pods of the DaemonSet to be updated and ready on every node
+// it is scheduled to. On timeout the returned error reports desired and ready counts and the nodes
+// whose pod is not ready.
+func (c *CLI) WaitForDaemonSetReady(namespace, name string, timeout time.Duration) error {
+	ds, status, err := waitForDaemonSet(c, namespace, name, timeout, func(ds *appsv1.DaemonSet) (bool, string) {
+		status := fmt.Sprintf("desired=%d ready=%d updated=%d", ds.Status.DesiredNumberScheduled, ds.Status.NumberReady, ds.Status.UpdatedNumberScheduled)
+		return ds.Status.ObservedGeneration >= ds.Generation &&
+			ds.Status.NumberReady == ds.Status.DesiredNumberScheduled &&
+			ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled, status
+	})
+	if err != nil {
+		if ds == nil {
+			return fmt.Errorf("daemonset %s/%s was not ready: %w (daemonset not observed)", namespace, name, err)
+		}
+		return fmt.Errorf("daemonset %s/%s was not ready: %w (%s; not ready nodes: %s)", namespace, name, err, status, notReadyDaemonSetNodes(c, ds))
+	}
+	e2e.Logf("DaemonSet %s/%s is ready", namespace, name)
+	return nil
+}
+
+// notReadyDaemonSetNodes returns the names of the nodes whose DaemonSet pod is not ready or outdated.
+func notReadyDaemonSetNodes(c *CLI, ds *appsv1.DaemonSet) string {
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return fmt.Sprintf("<invalid selector: %v>", err)
+	}
+	pods, err := c.AdminKubeClient().CoreV1().Pods(ds.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Sprintf("<unable to list pods: %v>", err)
+	}
+	upToDate := daemonSetPodUpToDate(ds)
+	var nodes []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !isPodReady(pod) || !upToDate(pod) {
+			nodes = append(nodes, pod.Spec.NodeName)
+		}
+	}
+	if len(nodes) == 0 {
+		return "<none>"
+	}
+	sort.Strings(nodes)
+	return strings.Join(nodes, ", ")
+}
+
+func waitForDaemonSet(oc *CLI, ns, name string, timeout time.Duration, condition func(*appsv1.DaemonSet) (bool, string)) (*appsv1.DaemonSet, string, error) {
+	client := oc.AdminKubeClient().AppsV1().DaemonSets(ns)
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return client.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return client.Watch(ctx, options)
+		},
+	}
+
+	var ds *appsv1.DaemonSet
+	var status string
+	_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.DaemonSet{}, nil, func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			ds = event.Object.(*appsv1.DaemonSet)
+			var done bool
+			done, status = condition(ds)
+			return done, nil
+		case watch.Deleted:
+			return false, fmt.Errorf("daemonset %s/%s was deleted", ns, name)
+		}
+		return false, nil
+	})
+	return ds, status, err
+}
+
+// daemonSetRolledOut mirrors the rollout status logic of kubectl. Only availability is checked
+// for the OnDelete strategy, which only updates pods as they are deleted.
+func daemonSetRolledOut(ds *appsv1.DaemonSet) (bool, string) {
+	status := fmt.Sprintf("desired=%d updated=%d ready=%d available=%d",
+		ds.Status.DesiredNumberScheduled, ds.Status.UpdatedNumberScheduled, ds.Status.NumberReady, ds.Status.NumberAvailable)
+	switch {
+	case ds.Status.ObservedGeneration < ds.Generation:
+		return false, "waiting for the daemonset spec update to be observed"
+	case ds.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType && ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled:
+		return false, status
+	}
+	return ds.Status.NumberAvailable >= ds.Status.DesiredNumberScheduled, status
+}
+
+// daemonSetPodUpToDate returns a function reporting whether a pod was created from the current
+// template of the DaemonSet.
+func daemonSetPodUpToDate(ds *appsv1.DaemonSet) func(*corev1.Pod) bool {
+	generation := strconv.FormatInt(ds.Generation, 10)
+	return func(pod *corev1.Pod) bool {
+		podGeneration, ok := pod.Labels[daemonSetTemplateGenerationLabel]
+		return !ok || podGeneration == generation
+	}
+}
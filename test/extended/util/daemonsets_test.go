@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func TestDaemonSetRolledOut(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy appsv1.DaemonSetUpdateStrategyType
+		status   appsv1.DaemonSetStatus
+		want     bool
+	}{
+		{
+			name:   "not observed",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 1},
+		},
+		{
+			name:   "not scheduled to any node",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 2},
+			want:   true,
+		},
+		{
+			name:   "update in progress",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 2, NumberAvailable: 3},
+		},
+		{
+			name:   "updated but not available",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+		},
+		{
+			name:   "rolled out",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+			want:   true,
+		},
+		{
+			name:     "on delete ignores updated pods",
+			strategy: appsv1.OnDeleteDaemonSetStrategyType,
+			status:   appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 1, NumberAvailable: 3},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &appsv1.DaemonSet{Status: tt.status}
+			ds.Generation = 2
+			ds.Spec.UpdateStrategy.Type = tt.strategy
+			if got, status := daemonSetRolledOut(ds); got != tt.want {
+				t.Errorf("expected %v, got %v (%s)", tt.want, got, status)
+			}
+		})
+	}
+}
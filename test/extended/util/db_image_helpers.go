@@ -40,7 +40,7 @@ type ReplicaSet interface {
 // WaitForQueryOutputSatisfies will execute the query multiple times, until the
 // specified predicate function is return true.
 func WaitForQueryOutputSatisfies(oc *CLI, d Database, timeout time.Duration, admin bool, query string, predicate func(string) bool) error {
-	err := wait.Poll(5*time.Second, timeout, func() (bool, error) {
+	err := poll(5*time.Second, timeout, false, func() (bool, error) {
 		var (
 			out string
 			err error
@@ -87,7 +87,7 @@ func WaitForQueryOutputContains(oc *CLI, d Database, timeout time.Duration, admi
 
 // WaitUntilUp continuously waits for the server to become ready, up until timeout.
 func WaitUntilUp(oc *CLI, d Database, timeout time.Duration) error {
-	err := wait.Poll(2*time.Second, timeout, func() (bool, error) {
+	err := poll(2*time.Second, timeout, false, func() (bool, error) {
 		return d.IsReady(oc)
 	})
 	if err == wait.ErrWaitTimeout {
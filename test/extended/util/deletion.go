@@ -0,0 +1,102 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kapierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// DeleteOption changes how DeleteAndWaitGone deletes an object.
+type DeleteOption func(*deleteOptions)
+
+type deleteOptions struct {
+	forceRemoveFinalizers bool
+}
+
+// ForceRemoveFinalizers makes DeleteAndWaitGone clear the finalizers of the object after deleting
+// it, so that deletion completes even when the controllers owning the finalizers are broken or
+// gone. It bypasses whatever cleanup those finalizers guard and is only meant for cleanup paths
+// that must converge, never for exercising the deletion behavior of a controller.
+func ForceRemoveFinalizers() DeleteOption {
+	return func(o *deleteOptions) {
+		o.forceRemoveFinalizers = true
+	}
+}
+
+// DeleteAndWaitGone deletes the named object of the resource, which may be cluster scoped when ns
+// is empty, and waits until it no longer exists, so that it can be recreated under the same name.
+// An object that is already gone is not an error. On timeout the returned error lists the
+// finalizers still held by the object and how long ago its deletion was requested.
+func DeleteAndWaitGone(oc *CLI, gvr schema.GroupVersionResource, ns, name string, timeout time.Duration, opts ...DeleteOption) error {
+	options := &deleteOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	client := oc.AdminDynamicClient().Resource(gvr).Namespace(ns)
+	ctx := context.Background()
+
+	obj, err := client.Get(ctx, name, metav1.GetOptions{})
+	if kapierrs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// only the object observed here is waited for, a replacement created by someone else is not
+	uid := obj.GetUID()
+	err = client.Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if kapierrs.IsNotFound(err) || kapierrs.IsConflict(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if options.forceRemoveFinalizers {
+		e2e.Logf("Removing the finalizers of %s %s/%s", gvr.Resource, ns, name)
+		patch := []byte(fmt.Sprintf(`{"metadata":{"finalizers":null,"uid":%q}}`, uid))
+		_, err := client.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil && !kapierrs.IsNotFound(err) && !kapierrs.IsConflict(err) {
+			return fmt.Errorf("removing the finalizers of %s %s/%s: %w", gvr.Resource, ns, name, err)
+		}
+	}
+
+	var lastObserved *unstructured.Unstructured
+	err = pollUntilTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if kapierrs.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			e2e.Logf("Unable to get %s %s/%s: %v", gvr.Resource, ns, name, err)
+			return false, nil
+		}
+		if obj.GetUID() != uid {
+			return true, nil
+		}
+		lastObserved = obj
+		return false, nil
+	})
+	if err == nil {
+		return nil
+	}
+	if lastObserved == nil {
+		return fmt.Errorf("waiting for %s %s/%s to be deleted: %w", gvr.Resource, ns, name, err)
+	}
+	return fmt.Errorf("waiting for %s %s/%s to be deleted: %w, %s", gvr.Resource, ns, name, err, describeDeletion(lastObserved))
+}
+
+// describeDeletion summarizes what is holding up the deletion of obj.
+func describeDeletion(obj *unstructured.Unstructured) string {
+	deletionTimestamp := obj.GetDeletionTimestamp()
+	if deletionTimestamp == nil {
+		return "deletion has not been requested"
+	}
+	return fmt.Sprintf("deletion requested %s ago, remaining finalizers: %v", waitClock.Since(deletionTimestamp.Time).Round(time.Second), obj.GetFinalizers())
+}
@@ -3,12 +3,12 @@ package util
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
@@ -61,7 +61,7 @@ func WaitForDeploymentReadyWithTimeout(oc *CLI, deployName, namespace string, re
 		labelSelector string
 		getErr        error
 	)
-	pollErr := wait.PollUntilContextTimeout(context.Background(), defaultPollingTime, timeout, true, func(context.Context) (isReady bool, err error) {
+	pollErr := pollUntilTimeout(context.Background(), defaultPollingTime, timeout, true, func(context.Context) (isReady bool, err error) {
 		deployment, getErr = oc.AdminKubeClient().AppsV1().Deployments(namespace).Get(context.Background(), deployName, metav1.GetOptions{})
 		if getErr != nil {
 			e2e.Logf("Unable to retrieve deployment %q:\n%v", deployName, getErr)
@@ -110,3 +110,33 @@ func DumpDeploymentPodsLogs(oc *CLI, deployName, namespace, labelSelector string
 
 	DumpPodLogs(pods.Items, oc)
 }
+
+// WaitForDeploymentRevision waits for the Deployment to be at the revision, as recorded in its
+// deployment.kubernetes.io/revision annotation, and for that revision to be rolled out. Rolling
+// back creates a new revision from the template of the old one, so pass the expected new revision.
+// On timeout the returned error includes the last observed revision.
+func (c *CLI) WaitForDeploymentRevision(namespace, name string, revision int64, timeout time.Duration) error {
+	expected := strconv.FormatInt(revision, 10)
+	observed := "<none>"
+	var status string
+	err := pollUntilTimeout(context.Background(), defaultPollingTime, timeout, true, func(ctx context.Context) (bool, error) {
+		deployment, err := c.AdminKubeClient().AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			status = err.Error()
+			return false, nil
+		}
+		if value, ok := deployment.Annotations["deployment.kubernetes.io/revision"]; ok {
+			observed = value
+		}
+		if observed != expected {
+			return false, nil
+		}
+		var done bool
+		done, status, err = deploymentRolloutDone(deployment)
+		return done, err
+	})
+	if err != nil {
+		return fmt.Errorf("deployment %s/%s did not roll out revision %d: %w (observed revision %s; %s)", namespace, name, revision, err, observed, status)
+	}
+	return nil
+}
@@ -11,7 +11,6 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	kutilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 
@@ -27,7 +26,7 @@ func WaitForDeploymentConfig(kc kubernetes.Interface, dcClient appsv1clienttyped
 	var dc *appsv1.DeploymentConfig
 
 	start := time.Now()
-	err := wait.Poll(time.Second, 15*time.Minute, func() (done bool, err error) {
+	err := poll(time.Second, 15*time.Minute, false, func() (done bool, err error) {
 		dc, err = dcClient.DeploymentConfigs(namespace).Get(context.Background(), name, metav1.GetOptions{})
 		if err != nil {
 			return false, err
@@ -108,7 +107,7 @@ func RemoveDeploymentConfigs(oc *CLI, dcs ...string) error {
 			errs = append(errs, err)
 		}
 
-		err := wait.PollImmediate(5*time.Second, 5*time.Minute, func() (bool, error) {
+		err := poll(5*time.Second, 5*time.Minute, true, func() (bool, error) {
 			pods, err := GetApplicationPods(oc, dc)
 			if err != nil {
 				e2e.Logf("Unable to get pods for dc/%s: %v", dc, err)
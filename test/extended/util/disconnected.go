@@ -0,0 +1,190 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	o "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+// externalRegistryProbeURL is requested to decide whether the cluster can reach public registries.
+const externalRegistryProbeURL = "https://quay.io/v2/"
+
+// imageMirrorRule is a source repository and the mirrors that may serve its images, in order of priority.
+type imageMirrorRule struct {
+	source  string
+	mirrors []string
+}
+
+// IsDisconnectedCluster returns true if the cluster has image mirroring configured through
+// ImageDigestMirrorSets or ImageContentSourcePolicies and the external registry cannot be
+// reached from the cluster through the cluster proxy.
+func IsDisconnectedCluster(oc *CLI) (bool, error) {
+	digestRules, _, err := getImageMirrorRules(oc)
+	if err != nil {
+		return false, err
+	}
+	if len(digestRules) == 0 {
+		return false, nil
+	}
+	reachable, err := isExternalRegistryReachable(oc)
+	if err != nil {
+		return false, err
+	}
+	return !reachable, nil
+}
+
+// MirroredImageRef returns the reference rewritten by the first mirror configured for it:
+// ImageDigestMirrorSets and ImageContentSourcePolicies apply to digest references and
+// ImageTagMirrorSets to tag references. The reference is returned unchanged if no mirror
+// applies.
+func MirroredImageRef(oc *CLI, ref string) (string, error) {
+	digestRules, tagRules, err := getImageMirrorRules(oc)
+	if err != nil {
+		return "", err
+	}
+	return applyImageMirrorRules(ref, digestRules, tagRules)
+}
+
+// SkipIfDisconnected skips the test if the cluster is disconnected.
+func (c *CLI) SkipIfDisconnected(reason string) {
+	disconnected, err := IsDisconnectedCluster(c)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the cluster is disconnected")
+	if disconnected {
+		skipper.Skipf("Skipping on a disconnected cluster: %s", reason)
+	}
+}
+
+// getImageMirrorRules reads the digest and tag mirror rules configured in the cluster.
+func getImageMirrorRules(oc *CLI) (digestRules, tagRules []imageMirrorRule, err error) {
+	ctx := context.Background()
+	idms, err := oc.AdminConfigClient().ConfigV1().ImageDigestMirrorSets().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list ImageDigestMirrorSets: %w", err)
+	}
+	icsp, err := oc.AdminOperatorClient().OperatorV1alpha1().ImageContentSourcePolicies().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list ImageContentSourcePolicies: %w", err)
+	}
+	itms, err := oc.AdminConfigClient().ConfigV1().ImageTagMirrorSets().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list ImageTagMirrorSets: %w", err)
+	}
+	return digestMirrorRules(idms.Items, icsp.Items), tagMirrorRules(itms.Items), nil
+}
+
+func digestMirrorRules(idms []configv1.ImageDigestMirrorSet, icsp []operatorv1alpha1.ImageContentSourcePolicy) []imageMirrorRule {
+	var rules []imageMirrorRule
+	for _, set := range idms {
+		for _, m := range set.Spec.ImageDigestMirrors {
+			rule := imageMirrorRule{source: m.Source}
+			for _, mirror := range m.Mirrors {
+				rule.mirrors = append(rule.mirrors, string(mirror))
+			}
+			rules = append(rules, rule)
+		}
+	}
+	for _, policy := range icsp {
+		for _, m := range policy.Spec.RepositoryDigestMirrors {
+			rules = append(rules, imageMirrorRule{source: m.Source, mirrors: m.Mirrors})
+		}
+	}
+	return rules
+}
+
+func tagMirrorRules(itms []configv1.ImageTagMirrorSet) []imageMirrorRule {
+	var rules []imageMirrorRule
+	for _, set := range itms {
+		for _, m := range set.Spec.ImageTagMirrors {
+			rule := imageMirrorRule{source: m.Source}
+			for _, mirror := range m.Mirrors {
+				rule.mirrors = append(rule.mirrors, string(mirror))
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// applyImageMirrorRules rewrites ref using the first mirror of the most specific rule whose
+// source matches the repository of ref, the same way the container runtime picks a mirror.
+func applyImageMirrorRules(ref string, digestRules, tagRules []imageMirrorRule) (string, error) {
+	parsed, err := reference.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %w", ref, err)
+	}
+	rules := tagRules
+	if len(parsed.ID) > 0 {
+		rules = digestRules
+	}
+
+	repository := parsed.AsRepository().Exact()
+	var match *imageMirrorRule
+	for i := range rules {
+		rule := &rules[i]
+		if len(rule.mirrors) == 0 {
+			continue
+		}
+		if repository != rule.source && !strings.HasPrefix(repository, rule.source+"/") {
+			continue
+		}
+		if match == nil || len(rule.source) > len(match.source) {
+			match = rule
+		}
+	}
+	if match == nil {
+		return ref, nil
+	}
+
+	mirrored := match.mirrors[0] + strings.TrimPrefix(repository, match.source)
+	switch {
+	case len(parsed.ID) > 0:
+		mirrored += "@" + parsed.ID
+	case len(parsed.Tag) > 0:
+		mirrored += ":" + parsed.Tag
+	}
+	return mirrored, nil
+}
+
+// isExternalRegistryReachable returns true if the external registry answers a request made from a
+// worker node through the cluster proxy. The request is made from the cluster rather than from the
+// test runner, which often has internet access when the cluster does not.
+func isExternalRegistryReachable(oc *CLI) (bool, error) {
+	httpProxy, httpsProxy, noProxy, err := oc.ClusterProxy()
+	if err != nil {
+		return false, err
+	}
+	node, err := oc.FirstNodeWithRole("worker")
+	if err != nil {
+		return false, err
+	}
+	stdout, stderr, err := DebugNode(oc, node.Name, "env",
+		"HTTP_PROXY="+httpProxy, "HTTPS_PROXY="+httpsProxy, "NO_PROXY="+noProxy,
+		"sh", "-c", fmt.Sprintf("curl -s -o /dev/null -w '%%{http_code}' --max-time 10 %s || true", externalRegistryProbeURL))
+	if err != nil {
+		return false, fmt.Errorf("unable to probe %s from node %s: %w: %s", externalRegistryProbeURL, node.Name, err, stderr)
+	}
+	reachable := registryProbeSucceeded(stdout)
+	if !reachable {
+		e2e.Logf("External registry %s is not reachable from node %s, HTTP status %q", externalRegistryProbeURL, node.Name, strings.TrimSpace(stdout))
+	}
+	return reachable, nil
+}
+
+// registryProbeSucceeded returns true if the HTTP status printed by curl shows that the registry
+// answered. curl prints 000 when no response was received.
+func registryProbeSucceeded(httpCode string) bool {
+	code, err := strconv.Atoi(strings.TrimSpace(httpCode))
+	return err == nil && code > 0 && code < http.StatusInternalServerError
+}
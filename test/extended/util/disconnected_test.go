@@ -0,0 +1,120 @@
+package util
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+)
+
+const mirrorTestDigest = "sha256:7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"
+
+func TestApplyImageMirrorRules(t *testing.T) {
+	idms := []configv1.ImageDigestMirrorSet{
+		{
+			Spec: configv1.ImageDigestMirrorSetSpec{
+				ImageDigestMirrors: []configv1.ImageDigestMirrors{
+					{Source: "quay.io", Mirrors: []configv1.ImageMirror{"mirror.example.com:5000/quay"}},
+					{Source: "quay.io/openshift/origin", Mirrors: []configv1.ImageMirror{"mirror.example.com:5000/origin", "backup.example.com/origin"}},
+					{Source: "registry.redhat.io/ubi9", Mirrors: nil},
+				},
+			},
+		},
+	}
+	icsp := []operatorv1alpha1.ImageContentSourcePolicy{
+		{
+			Spec: operatorv1alpha1.ImageContentSourcePolicySpec{
+				RepositoryDigestMirrors: []operatorv1alpha1.RepositoryDigestMirrors{
+					{Source: "registry.redhat.io/ubi8/ubi", Mirrors: []string{"mirror.example.com:5000/ubi8/ubi"}},
+				},
+			},
+		},
+	}
+	itms := []configv1.ImageTagMirrorSet{
+		{
+			Spec: configv1.ImageTagMirrorSetSpec{
+				ImageTagMirrors: []configv1.ImageTagMirrors{
+					{Source: "docker.io/library", Mirrors: []configv1.ImageMirror{"mirror.example.com:5000/library"}},
+				},
+			},
+		},
+	}
+	digestRules := digestMirrorRules(idms, icsp)
+	tagRules := tagMirrorRules(itms)
+
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{
+			name:     "digest matching a registry source",
+			ref:      "quay.io/foo/bar@" + mirrorTestDigest,
+			expected: "mirror.example.com:5000/quay/foo/bar@" + mirrorTestDigest,
+		},
+		{
+			name:     "digest prefers the most specific source",
+			ref:      "quay.io/openshift/origin/tests@" + mirrorTestDigest,
+			expected: "mirror.example.com:5000/origin/tests@" + mirrorTestDigest,
+		},
+		{
+			name:     "digest matching an ICSP source",
+			ref:      "registry.redhat.io/ubi8/ubi@" + mirrorTestDigest,
+			expected: "mirror.example.com:5000/ubi8/ubi@" + mirrorTestDigest,
+		},
+		{
+			name:     "source without mirrors is ignored",
+			ref:      "registry.redhat.io/ubi9/ubi@" + mirrorTestDigest,
+			expected: "registry.redhat.io/ubi9/ubi@" + mirrorTestDigest,
+		},
+		{
+			name:     "source only matches whole path components",
+			ref:      "registry.redhat.io/ubi8/ubi-minimal@" + mirrorTestDigest,
+			expected: "registry.redhat.io/ubi8/ubi-minimal@" + mirrorTestDigest,
+		},
+		{
+			name:     "tag is not rewritten by digest mirrors",
+			ref:      "quay.io/foo/bar:latest",
+			expected: "quay.io/foo/bar:latest",
+		},
+		{
+			name:     "tag matching a tag mirror",
+			ref:      "docker.io/library/busybox:1.36",
+			expected: "mirror.example.com:5000/library/busybox:1.36",
+		},
+		{
+			name:     "unmatched reference",
+			ref:      "gcr.io/foo/bar@" + mirrorTestDigest,
+			expected: "gcr.io/foo/bar@" + mirrorTestDigest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := applyImageMirrorRules(test.ref, digestRules, tagRules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+
+	if _, err := applyImageMirrorRules("Invalid Reference", digestRules, tagRules); err == nil {
+		t.Errorf("expected an error for an invalid reference")
+	}
+}
+
+func TestRegistryProbeSucceeded(t *testing.T) {
+	for code, expected := range map[string]bool{
+		"200":   true,
+		"401\n": true,
+		"000":   false,
+		"503":   false,
+		"":      false,
+	} {
+		if actual := registryProbeSucceeded(code); actual != expected {
+			t.Errorf("%q: expected %v, got %v", code, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// ClusterDNSBaseDomain returns the base domain of the cluster from the DNS config object.
+func ClusterDNSBaseDomain(oc *CLI) (string, error) {
+	dns, err := oc.AdminConfigClient().ConfigV1().DNSes().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if len(dns.Spec.BaseDomain) == 0 {
+		return "", fmt.Errorf("the cluster DNS config has no spec.baseDomain")
+	}
+	return dns.Spec.BaseDomain, nil
+}
+
+// WaitForDNSResolution waits until host resolves through the nameservers configured
+// for the test process and returns the resolved addresses. Freshly created route
+// hosts can take a while to propagate in some environments.
+func WaitForDNSResolution(host string, timeout time.Duration) ([]string, error) {
+	var (
+		addrs   []string
+		lastErr error
+	)
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		addrs, lastErr = net.DefaultResolver.LookupHost(ctx, host)
+		if lastErr != nil {
+			e2e.Logf("Unable to resolve %q yet: %v", host, lastErr)
+			return false, nil
+		}
+		return len(addrs) > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("host %q did not resolve within %s: %w (last error: %v)", host, timeout, err, lastErr)
+	}
+	return addrs, nil
+}
@@ -2,15 +2,29 @@ package util
 
 import (
 	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
 func WaitForEndpointsAvailable(oc *CLI, serviceName string) error {
-	return wait.Poll(200*time.Millisecond, 3*time.Minute, func() (bool, error) {
+	return poll(200*time.Millisecond, 3*time.Minute, false, func() (bool, error) {
 		ep, err := oc.KubeClient().CoreV1().Endpoints(oc.Namespace()).Get(context.Background(), serviceName, metav1.GetOptions{})
 		if err != nil && !errors.IsNotFound(err) {
 			return false, err
@@ -19,3 +33,153 @@ func WaitForEndpointsAvailable(oc *CLI, serviceName string) error {
 		return (len(ep.Subsets) > 0) && (len(ep.Subsets[0].Addresses) > 0), nil
 	})
 }
+
+// WaitForEndpointsReady waits for the service to have at least minAddresses distinct ready
+// addresses in its EndpointSlices, or in its Endpoints when the EndpointSlice API is not served.
+// Ready endpoints are not yet programmed by kube-proxy on every node, so callers connecting to
+// the service should still retry, e.g. with ProbeServiceFromPod.
+func WaitForEndpointsReady(oc *CLI, ns, svc string, minAddresses int, timeout time.Duration) error {
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := oc.AdminKubeClient()
+	var ready sets.Set[string]
+	var err error
+	if _, listErr := client.DiscoveryV1().EndpointSlices(ns).List(ctx, metav1.ListOptions{Limit: 1}); errors.IsNotFound(listErr) {
+		e2e.Logf("EndpointSlices are not served, waiting for the Endpoints of service %s/%s", ns, svc)
+		fieldSelector := fields.OneTermEqualSelector("metadata.name", svc).String()
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = fieldSelector
+				return client.CoreV1().Endpoints(ns).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = fieldSelector
+				return client.CoreV1().Endpoints(ns).Watch(ctx, options)
+			},
+		}
+		_, err = watchtools.UntilWithSync(ctx, lw, &corev1.Endpoints{}, nil, func(event watch.Event) (bool, error) {
+			ready = sets.New[string]()
+			if endpoints, ok := event.Object.(*corev1.Endpoints); ok && event.Type != watch.Deleted {
+				ready = readyEndpointsAddresses(endpoints)
+			}
+			return ready.Len() >= minAddresses, nil
+		})
+	} else {
+		labelSelector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: svc}).String()
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = labelSelector
+				return client.DiscoveryV1().EndpointSlices(ns).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = labelSelector
+				return client.DiscoveryV1().EndpointSlices(ns).Watch(ctx, options)
+			},
+		}
+		// a service has one slice per address family and per hundred endpoints
+		slices := map[string]*discoveryv1.EndpointSlice{}
+		_, err = watchtools.UntilWithSync(ctx, lw, &discoveryv1.EndpointSlice{}, nil, func(event watch.Event) (bool, error) {
+			slice, ok := event.Object.(*discoveryv1.EndpointSlice)
+			if !ok {
+				return false, nil
+			}
+			if event.Type == watch.Deleted {
+				delete(slices, slice.Name)
+			} else {
+				slices[slice.Name] = slice
+			}
+			ready = readyEndpointSliceAddresses(slices)
+			return ready.Len() >= minAddresses, nil
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("service %s/%s has %d ready addresses %v instead of at least %d: %w", ns, svc, ready.Len(), sets.List(ready), minAddresses, err)
+	}
+	e2e.Logf("Service %s/%s has %d ready addresses", ns, svc, ready.Len())
+	return nil
+}
+
+// readyEndpointSliceAddresses returns the addresses of the ready endpoints of the slices. An
+// endpoint whose readiness is unknown is ready, as for kube-proxy.
+func readyEndpointSliceAddresses(slices map[string]*discoveryv1.EndpointSlice) sets.Set[string] {
+	ready := sets.New[string]()
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready.Insert(endpoint.Addresses...)
+			}
+		}
+	}
+	return ready
+}
+
+func readyEndpointsAddresses(endpoints *corev1.Endpoints) sets.Set[string] {
+	ready := sets.New[string]()
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			ready.Insert(address.IP)
+		}
+	}
+	return ready
+}
+
+// ProbeServiceFromPod connects to the port of the service from the exec pod, created e.g. with
+// CreateExecPod, retrying until a connection succeeds or timeout elapses. svcDNS may be the name
+// of the service, its cluster DNS name or its IP. On failure the returned error counts the kinds
+// of errors observed, connection refused, timeout or DNS resolution, which tell apart a service
+// without endpoints from one that is not programmed or not resolvable.
+func ProbeServiceFromPod(oc *CLI, execPodNs, execPod, svcDNS string, port int, timeout time.Duration) error {
+	failures := map[string]int{}
+	var lastFailure string
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		_, stderr, err := ExecInPod(ctx, oc.AsAdmin(), execPodNs, execPod, "", "nc", "-z", "-w", "5", svcDNS, strconv.Itoa(port))
+		if err == nil {
+			return true, nil
+		}
+		kind := "exec"
+		var execErr *ExecError
+		if goerrors.As(err, &execErr) {
+			kind = classifyConnectionFailure(stderr)
+		}
+		failures[kind]++
+		lastFailure = strings.TrimSpace(fmt.Sprintf("%v %s", err, stderr))
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to connect to %s:%d from pod %s/%s, observed %s (last: %s): %w",
+			svcDNS, port, execPodNs, execPod, describeConnectionFailures(failures), lastFailure, err)
+	}
+	e2e.Logf("Connected to %s:%d from pod %s/%s", svcDNS, port, execPodNs, execPod)
+	return nil
+}
+
+// classifyConnectionFailure returns the kind of failure nc reported on stderr, for both the ncat
+// and busybox variants.
+func classifyConnectionFailure(stderr string) string {
+	message := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(message, "refused"):
+		return "refused"
+	case strings.Contains(message, "timed out"), strings.Contains(message, "timeout"):
+		return "timeout"
+	case strings.Contains(message, "resolve"), strings.Contains(message, "bad address"),
+		strings.Contains(message, "name or service not known"), strings.Contains(message, "unknown host"):
+		return "dns"
+	case strings.Contains(message, "no route to host"), strings.Contains(message, "unreachable"):
+		return "unreachable"
+	}
+	return "other"
+}
+
+func describeConnectionFailures(failures map[string]int) string {
+	if len(failures) == 0 {
+		return "no failure"
+	}
+	var kinds []string
+	for kind, count := range failures {
+		kinds = append(kinds, fmt.Sprintf("%s=%d", kind, count))
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, ", ")
+}
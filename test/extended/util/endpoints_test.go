@@ -0,0 +1,49 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestReadyEndpointSliceAddresses(t *testing.T) {
+	ready, notReady := true, false
+	slices := map[string]*discoveryv1.EndpointSlice{
+		"web-ipv4": {Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.128.0.10"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+			{Addresses: []string{"10.128.0.11"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+			{Addresses: []string{"10.128.0.12"}},
+		}},
+		"web-ipv4-duplicate": {Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.128.0.10"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+		}},
+		"web-ipv6": {Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"fd01::10"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+		}},
+	}
+	if got := sets.List(readyEndpointSliceAddresses(slices)); !reflect.DeepEqual(got, []string{"10.128.0.10", "10.128.0.12", "fd01::10"}) {
+		t.Errorf("unexpected ready addresses %v", got)
+	}
+}
+
+func TestClassifyConnectionFailure(t *testing.T) {
+	for stderr, want := range map[string]string{
+		"Ncat: Connection refused.": "refused",
+		"nc: can't connect to remote host (172.30.0.10): Connection refused": "refused",
+		"Ncat: TIMEOUT.":              "timeout",
+		"Ncat: Connection timed out.": "timeout",
+		"Ncat: Could not resolve hostname \"web.e2e.svc\": Name or service not known. QUITTING.": "dns",
+		"nc: bad address 'web.e2e.svc'": "dns",
+		"Ncat: No route to host.":       "unreachable",
+		"":                              "other",
+	} {
+		if got := classifyConnectionFailure(stderr); got != want {
+			t.Errorf("classifyConnectionFailure(%q) = %q, want %q", stderr, got, want)
+		}
+	}
+	if got := describeConnectionFailures(map[string]int{"timeout": 2, "dns": 1}); got != "dns=1, timeout=2" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// eventResyncPeriod bounds how long WaitForEvent relies on a single watch before listing the
+// events again, so that an event missed by a broken watch is still found.
+const eventResyncPeriod = time.Minute
+
+// EventMatch describes the events WaitForEvent waits for. Empty fields match any event.
+type EventMatch struct {
+	// InvolvedObjectKind is the kind of the object the event is about, e.g. Pod.
+	InvolvedObjectKind string
+	// InvolvedObjectName is the name of the object the event is about.
+	InvolvedObjectName string
+	// Reason is the reason of the event, e.g. FailedScheduling.
+	Reason string
+	// Message, if set, must match the message of the event.
+	Message *regexp.Regexp
+	// MinCount is the number of times the event must have happened. Defaults to 1.
+	MinCount int32
+}
+
+// Matches returns true if the event matches, ignoring MinCount.
+func (m EventMatch) Matches(event *corev1.Event) bool {
+	switch {
+	case len(m.InvolvedObjectKind) > 0 && event.InvolvedObject.Kind != m.InvolvedObjectKind:
+		return false
+	case len(m.InvolvedObjectName) > 0 && event.InvolvedObject.Name != m.InvolvedObjectName:
+		return false
+	case len(m.Reason) > 0 && event.Reason != m.Reason:
+		return false
+	case m.Message != nil && !m.Message.MatchString(event.Message):
+		return false
+	}
+	return true
+}
+
+// fieldSelector returns a server side selector for the fields of the match that support it.
+func (m EventMatch) fieldSelector() fields.Selector {
+	set := fields.Set{}
+	if len(m.InvolvedObjectKind) > 0 {
+		set["involvedObject.kind"] = m.InvolvedObjectKind
+	}
+	if len(m.InvolvedObjectName) > 0 {
+		set["involvedObject.name"] = m.InvolvedObjectName
+	}
+	if len(m.Reason) > 0 {
+		set["reason"] = m.Reason
+	}
+	return set.AsSelector()
+}
+
+func (m EventMatch) String() string {
+	message := ""
+	if m.Message != nil {
+		message = m.Message.String()
+	}
+	return fmt.Sprintf("kind=%q name=%q reason=%q message=%q count>=%d", m.InvolvedObjectKind, m.InvolvedObjectName, m.Reason, message, max(m.MinCount, 1))
+}
+
+// EventCount returns the number of times the event happened. Events recorded with the
+// events.k8s.io/v1 API keep the count in their series instead of the count field.
+func EventCount(event *corev1.Event) int32 {
+	count := event.Count
+	if event.Series != nil && event.Series.Count > count {
+		count = event.Series.Count
+	}
+	return max(count, 1)
+}
+
+// WaitForEvent waits for an event of the namespace to match and to have happened at least
+// match.MinCount times, and returns it; use EventCount for the number of times it happened.
+// Events recorded with either the core/v1 or the events.k8s.io/v1 API are found.
+func WaitForEvent(oc *CLI, ns string, match EventMatch, timeout time.Duration) (*corev1.Event, error) {
+	client := oc.AdminKubeClient().CoreV1().Events(ns)
+	fieldSelector := match.fieldSelector().String()
+	minCount := max(match.MinCount, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var found *corev1.Event
+	for {
+		resyncCtx, cancelResync := context.WithTimeout(ctx, eventResyncPeriod)
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = fieldSelector
+				return client.List(resyncCtx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = fieldSelector
+				return client.Watch(resyncCtx, options)
+			},
+		}
+		_, err := watchtools.UntilWithSync(resyncCtx, lw, &corev1.Event{}, nil, func(event watch.Event) (bool, error) {
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				e := event.Object.(*corev1.Event)
+				if match.Matches(e) && EventCount(e) >= minCount {
+					found = e
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		resynced := resyncCtx.Err() != nil
+		cancelResync()
+		switch {
+		case err == nil:
+			return found, nil
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("timed out waiting for an event in %s matching %s: %w", ns, match, ctx.Err())
+		case resynced, errors.Is(err, watchtools.ErrWatchClosed):
+			// list again
+		default:
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"regexp"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestEventMatch(t *testing.T) {
+	event := &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		Reason:         "FailedScheduling",
+		Message:        "0/6 nodes are available: 3 Insufficient cpu.",
+	}
+	tests := []struct {
+		name  string
+		match EventMatch
+		want  bool
+	}{
+		{name: "empty match", match: EventMatch{}, want: true},
+		{name: "all fields", match: EventMatch{InvolvedObjectKind: "Pod", InvolvedObjectName: "web-1", Reason: "FailedScheduling", Message: regexp.MustCompile(`Insufficient (cpu|memory)`)}, want: true},
+		{name: "other kind", match: EventMatch{InvolvedObjectKind: "Node"}},
+		{name: "other name", match: EventMatch{InvolvedObjectName: "web-2"}},
+		{name: "other reason", match: EventMatch{Reason: "Scheduled"}},
+		{name: "other message", match: EventMatch{Message: regexp.MustCompile(`Insufficient memory`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(event); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		event corev1.Event
+		want  int32
+	}{
+		{name: "core event without count", want: 1},
+		{name: "core event with count", event: corev1.Event{Count: 4}, want: 4},
+		{name: "events.k8s.io event with series", event: corev1.Event{Series: &corev1.EventSeries{Count: 7}}, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EventCount(&tt.event); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,12 +2,20 @@ package util
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubernetes/test/e2e/framework"
 )
@@ -37,3 +45,144 @@ func ExecInPodWithResult(podClient coreclientset.CoreV1Interface, podRESTConfig
 	}
 	return buf.String(), nil
 }
+
+// execRetryTimeout bounds the retries of an exec into a container that is still starting.
+const execRetryTimeout = time.Minute
+
+// newExecutor creates the executor used by ExecInPod, it is replaced in unit tests.
+var newExecutor = func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+	return remotecommand.NewSPDYExecutor(config, method, url)
+}
+
+// ExecError is returned by ExecInPod when the command ran and exited with a non-zero code,
+// as opposed to failures to reach the container.
+type ExecError struct {
+	Command  []string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("command %q exited with code %d: %v (stderr: %s)", strings.Join(e.Command, " "), e.ExitCode, e.Err, e.Stderr)
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// ExecInPod runs the command in the container of the pod using the current config of the
+// CLI, i.e. the admin config after AsAdmin. A non-zero exit of the command is returned as an
+// *ExecError, any other error means the command could not be run. Execs into a container
+// that is not started yet are retried for up to a minute.
+func ExecInPod(ctx context.Context, oc *CLI, ns, pod, container string, command ...string) (stdout, stderr string, err error) {
+	return ExecInPodWithInput(ctx, oc, ns, pod, container, nil, command...)
+}
+
+// ExecInPodWithInput is like ExecInPod but passes stdin to the command. The input is read
+// into memory first, so that it is sent from the start again when the exec is retried.
+func ExecInPodWithInput(ctx context.Context, oc *CLI, ns, pod, container string, stdin io.Reader, command ...string) (stdout, stderr string, err error) {
+	var input []byte
+	if stdin != nil {
+		input, err = io.ReadAll(stdin)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read the input of command %q: %w", strings.Join(command, " "), err)
+		}
+	}
+	config, u, err := execURL(oc, ns, pod, container, stdin != nil, command)
+	if err != nil {
+		return "", "", err
+	}
+	return execWithRetries(ctx, config, u, input, command)
+}
+
+// execInPodStreaming is like ExecInPodWithInput but writes the output of the command to
+// stdout as it is received. It is not retried since the output may be partially written.
+func execInPodStreaming(ctx context.Context, oc *CLI, ns, pod, container string, stdin io.Reader, stdout io.Writer, command ...string) (stderr string, err error) {
+	config, u, err := execURL(oc, ns, pod, container, stdin != nil, command)
+	if err != nil {
+		return "", err
+	}
+	stderrBuf := &bytes.Buffer{}
+	err = streamExec(ctx, config, u, stdin, stdout, stderrBuf)
+	return stderrBuf.String(), classifyExecError(err, command, stderrBuf.String())
+}
+
+// execURL returns the config and the exec URL for the command in the container of the pod.
+func execURL(oc *CLI, ns, pod, container string, stdin bool, command []string) (*rest.Config, *url.URL, error) {
+	config := oc.UserConfig()
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := client.CoreV1().RESTClient().Post().Resource("pods").Namespace(ns).Name(pod).SubResource("exec").VersionedParams(&v1.PodExecOptions{
+		Container: container,
+		Stdin:     stdin,
+		Stdout:    true,
+		Stderr:    true,
+		Command:   command,
+	}, scheme.ParameterCodec).URL()
+	return config, u, nil
+}
+
+// execWithRetries runs the command, passing input to its stdin unless input is nil.
+func execWithRetries(ctx context.Context, config *rest.Config, u *url.URL, input []byte, command []string) (string, string, error) {
+	var stdout, stderr string
+	var execErr error
+	err := pollUntilTimeout(ctx, 2*time.Second, execRetryTimeout, true, func(ctx context.Context) (bool, error) {
+		var stdin io.Reader
+		if input != nil {
+			stdin = bytes.NewReader(input)
+		}
+		stdout, stderr, execErr = execOnce(ctx, config, u, stdin, command)
+		if isContainerNotFound(execErr) {
+			framework.Logf("Container is not running yet, retrying exec: %v", execErr)
+			return false, nil
+		}
+		return true, nil
+	})
+	if execErr == nil && err != nil {
+		execErr = err
+	}
+	return stdout, stderr, execErr
+}
+
+func execOnce(ctx context.Context, config *rest.Config, u *url.URL, stdin io.Reader, command []string) (string, string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := streamExec(ctx, config, u, stdin, stdout, stderr)
+	return stdout.String(), stderr.String(), classifyExecError(err, command, stderr.String())
+}
+
+func streamExec(ctx context.Context, config *rest.Config, u *url.URL, stdin io.Reader, stdout, stderr io.Writer) error {
+	e, err := newExecutor(config, "POST", u)
+	if err != nil {
+		return fmt.Errorf("could not initialize a new SPDY executor: %w", err)
+	}
+	return e.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// classifyExecError turns the exit of the remote command into an *ExecError and leaves
+// connection and setup errors untouched.
+func classifyExecError(err error, command []string, stderr string) error {
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		return &ExecError{Command: command, ExitCode: exitErr.ExitStatus(), Stderr: stderr, Err: err}
+	}
+	return err
+}
+
+// isContainerNotFound returns true for the errors returned while the container is starting.
+func isContainerNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	var execErr *ExecError
+	if errors.As(err, &execErr) {
+		return false
+	}
+	return strings.Contains(err.Error(), "container not found")
+}
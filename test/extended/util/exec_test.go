@@ -0,0 +1,139 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// fakeExecutor returns the next error of errs on each stream and writes output to stdout. The
+// stdin received by each stream is recorded in inputs.
+type fakeExecutor struct {
+	output string
+	errs   []error
+	calls  int
+	inputs []string
+}
+
+func (f *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	return f.StreamWithContext(context.Background(), options)
+}
+
+func (f *fakeExecutor) StreamWithContext(_ context.Context, options remotecommand.StreamOptions) error {
+	f.calls++
+	if options.Stdin != nil {
+		input, _ := io.ReadAll(options.Stdin)
+		f.inputs = append(f.inputs, string(input))
+	}
+	var err error
+	if len(f.errs) > 0 {
+		err, f.errs = f.errs[0], f.errs[1:]
+	}
+	if err == nil {
+		io.WriteString(options.Stdout, f.output)
+	}
+	io.WriteString(options.Stderr, "some stderr")
+	return err
+}
+
+func TestExecInPodErrorClassification(t *testing.T) {
+	containerNotFound := fmt.Errorf("unable to upgrade connection: container not found (\"test\")")
+	tests := []struct {
+		name             string
+		errs             []error
+		expectedStdout   string
+		expectedExitCode int
+		expectedConnErr  bool
+		expectedCalls    int
+	}{
+		{
+			name:           "success",
+			expectedStdout: "ok",
+			expectedCalls:  1,
+		},
+		{
+			name:             "command exited with non-zero code",
+			errs:             []error{utilexec.CodeExitError{Err: fmt.Errorf("command terminated with exit code 3"), Code: 3}},
+			expectedExitCode: 3,
+			expectedCalls:    1,
+		},
+		{
+			name:            "connection failure",
+			errs:            []error{fmt.Errorf("error dialing backend: dial tcp 10.0.0.1:10250: connect: connection refused")},
+			expectedConnErr: true,
+			expectedCalls:   1,
+		},
+		{
+			name:           "container not found is retried",
+			errs:           []error{containerNotFound},
+			expectedStdout: "ok",
+			expectedCalls:  2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := &fakeExecutor{output: "ok", errs: test.errs}
+			defer func(orig func(*rest.Config, string, *url.URL) (remotecommand.Executor, error)) { newExecutor = orig }(newExecutor)
+			newExecutor = func(*rest.Config, string, *url.URL) (remotecommand.Executor, error) {
+				return executor, nil
+			}
+
+			stdout, stderr, err := execWithRetries(context.Background(), &rest.Config{}, &url.URL{}, nil, []string{"false"})
+			if executor.calls != test.expectedCalls {
+				t.Errorf("expected %d exec calls, got %d", test.expectedCalls, executor.calls)
+			}
+			if stdout != test.expectedStdout {
+				t.Errorf("expected stdout %q, got %q", test.expectedStdout, stdout)
+			}
+			if stderr != "some stderr" {
+				t.Errorf("expected stderr to be returned, got %q", stderr)
+			}
+
+			var execErr *ExecError
+			switch {
+			case test.expectedExitCode != 0:
+				if !errors.As(err, &execErr) {
+					t.Fatalf("expected an ExecError, got %v", err)
+				}
+				if execErr.ExitCode != test.expectedExitCode {
+					t.Errorf("expected exit code %d, got %d", test.expectedExitCode, execErr.ExitCode)
+				}
+				if execErr.Stderr != "some stderr" {
+					t.Errorf("expected the ExecError to carry stderr, got %q", execErr.Stderr)
+				}
+			case test.expectedConnErr:
+				if err == nil || errors.As(err, &execErr) {
+					t.Errorf("expected a connection error, got %v", err)
+				}
+			default:
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestExecInPodRetryReplaysInput(t *testing.T) {
+	executor := &fakeExecutor{output: "ok", errs: []error{fmt.Errorf("unable to upgrade connection: container not found (\"test\")")}}
+	defer func(orig func(*rest.Config, string, *url.URL) (remotecommand.Executor, error)) { newExecutor = orig }(newExecutor)
+	newExecutor = func(*rest.Config, string, *url.URL) (remotecommand.Executor, error) {
+		return executor, nil
+	}
+
+	if _, _, err := execWithRetries(context.Background(), &rest.Config{}, &url.URL{}, []byte("input"), []string{"cat"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(executor.inputs, []string{"input", "input"}) {
+		t.Errorf("expected the input to be sent in full on each attempt, got %q", executor.inputs)
+	}
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	o "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// GetFeatureGates returns the enabled and disabled feature gates reported in the
+// FeatureGate status for the desired cluster version. It waits briefly for the
+// status to be populated.
+func GetFeatureGates(oc *CLI) (enabled, disabled sets.String, err error) {
+	ctx := context.Background()
+	cv, err := oc.AdminConfigClient().ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failure getting test cluster ClusterVersion: %w", err)
+	}
+	version := cv.Status.Desired.Version
+
+	var lastObserved []string
+	err = pollUntilTimeout(ctx, 2*time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+		featureGate, err := oc.AdminConfigClient().ConfigV1().FeatureGates().Get(ctx, "cluster", metav1.GetOptions{})
+		if err != nil {
+			e2e.Logf("Unable to retrieve the cluster FeatureGate: %v", err)
+			return false, nil
+		}
+		lastObserved = lastObserved[:0]
+		for _, details := range featureGate.Status.FeatureGates {
+			lastObserved = append(lastObserved, details.Version)
+			if details.Version != version {
+				continue
+			}
+			enabled, disabled = sets.NewString(), sets.NewString()
+			for _, gate := range details.Enabled {
+				enabled.Insert(string(gate.Name))
+			}
+			for _, gate := range details.Disabled {
+				disabled.Insert(string(gate.Name))
+			}
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("FeatureGate status has not been populated for version %q (found versions %v): %w", version, lastObserved, err)
+	}
+	return enabled, disabled, nil
+}
+
+// SkipUnlessFeatureGate skips the test unless the feature gate is enabled.
+func SkipUnlessFeatureGate(oc *CLI, gate configv1.FeatureGateName) {
+	enabled, _, err := GetFeatureGates(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the enabled feature gates")
+	if !enabled.Has(string(gate)) {
+		skipper.Skipf("Skipping because the %s feature gate is not enabled", gate)
+	}
+}
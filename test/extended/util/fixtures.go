@@ -0,0 +1,176 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+var fixtureParameterPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// ApplyFixture reads the manifest at path, which may hold several YAML documents, replaces the
+// ${KEY} placeholders with params and server-side applies each object. Namespaced objects without
+// a namespace go to the current namespace. The objects that did not exist before are deleted when
+// the test ends. A placeholder without a matching param is an error.
+func ApplyFixture(oc *CLI, path string, params map[string]string) ([]*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = substituteFixtureParameters(data, params)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process fixture %s: %w", path, err)
+	}
+	objects, err := decodeFixture(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode fixture %s: %w", path, err)
+	}
+	return applyObjects(oc, objects, "fixture "+path)
+}
+
+// ApplyAndWaitObserved server-side applies the objects of the manifest, which may hold several
+// YAML documents, like ApplyFixture does and waits for the controller of each object to observe
+// it, that is for status.observedGeneration to reach metadata.generation. Objects that have no
+// metadata.generation are rejected, and the error reports when status.observedGeneration was
+// never set.
+func (c *CLI) ApplyAndWaitObserved(manifest string, timeout time.Duration) error {
+	objects, err := decodeFixture([]byte(manifest))
+	if err != nil {
+		return fmt.Errorf("unable to decode manifest: %w", err)
+	}
+	applied, err := applyObjects(c, objects, "manifest")
+	if err != nil {
+		return err
+	}
+	mapper := c.AsAdmin().RESTMapper()
+	for _, obj := range applied {
+		if obj.GetGeneration() == 0 {
+			return fmt.Errorf("%s %s has no metadata.generation, its reconciliation cannot be observed", obj.GetKind(), obj.GetName())
+		}
+		resource, err := resourceFor(c, mapper, obj)
+		if err != nil {
+			return err
+		}
+		var observed, generation int64
+		var found bool
+		_, err = WaitForResourceCondition(c, resource, obj.GetNamespace(), obj.GetName(), func(current *unstructured.Unstructured) (bool, error) {
+			generation = current.GetGeneration()
+			var nestedErr error
+			observed, found, nestedErr = unstructured.NestedInt64(current.Object, "status", "observedGeneration")
+			if nestedErr != nil {
+				return false, fmt.Errorf("unexpected status.observedGeneration: %w", nestedErr)
+			}
+			return found && observed >= generation, nil
+		}, timeout)
+		if err != nil {
+			if !found {
+				return fmt.Errorf("%s %s never set status.observedGeneration: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			return fmt.Errorf("%s %s observed generation %d instead of %d: %w", obj.GetKind(), obj.GetName(), observed, generation, err)
+		}
+		e2e.Logf("%s %s generation %d was observed", obj.GetKind(), obj.GetName(), generation)
+	}
+	return nil
+}
+
+// applyObjects server-side applies the objects read from source, registering the ones that did
+// not exist before for deletion.
+func applyObjects(oc *CLI, objects []*unstructured.Unstructured, source string) ([]*unstructured.Unstructured, error) {
+	ctx := context.Background()
+	mapper := oc.AsAdmin().RESTMapper()
+	dynamicClient := oc.AdminDynamicClient()
+	var applied []*unstructured.Unstructured
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		resource, err := resourceFor(oc, mapper, obj)
+		if err != nil {
+			return applied, fmt.Errorf("unable to map %s from %s: %w", gvk, source, err)
+		}
+		client := dynamicClient.Resource(resource).Namespace(obj.GetNamespace())
+
+		_, err = client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		created := apierrors.IsNotFound(err)
+
+		result, err := client.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: "openshift-tests", Force: true})
+		if err != nil {
+			return applied, fmt.Errorf("unable to apply %s %s from %s: %w", gvk.Kind, obj.GetName(), source, err)
+		}
+		if created {
+			oc.AddResourceToDelete(resource, result)
+		}
+		applied = append(applied, result)
+	}
+	return applied, nil
+}
+
+// resourceFor returns the resource of the object and defaults the namespace of namespaced
+// objects to the current namespace.
+func resourceFor(oc *CLI, mapper meta.RESTMapper, obj *unstructured.Unstructured) (schema.GroupVersionResource, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && len(obj.GetNamespace()) == 0 {
+		obj.SetNamespace(oc.Namespace())
+	}
+	return mapping.Resource, nil
+}
+
+// substituteFixtureParameters replaces every ${KEY} in data with params[KEY], failing when a key
+// has no value.
+func substituteFixtureParameters(data []byte, params map[string]string) ([]byte, error) {
+	missing := map[string]bool{}
+	result := fixtureParameterPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		key := string(match[2 : len(match)-1])
+		value, ok := params[key]
+		if !ok {
+			missing[key] = true
+			return match
+		}
+		return []byte(value)
+	})
+	if len(missing) > 0 {
+		var keys []string
+		for key := range missing {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("no value for parameters %s", strings.Join(keys, ", "))
+	}
+	return result, nil
+}
+
+// decodeFixture decodes the YAML or JSON documents of a manifest, skipping empty ones.
+func decodeFixture(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
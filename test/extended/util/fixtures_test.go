@@ -0,0 +1,72 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestSubstituteFixtureParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		params  map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "all parameters set",
+			data:   "image: ${IMAGE}\nnamespace: ${NAMESPACE}\nname: ${IMAGE}-pod",
+			params: map[string]string{"IMAGE": "busybox", "NAMESPACE": "e2e"},
+			want:   "image: busybox\nnamespace: e2e\nname: busybox-pod",
+		},
+		{
+			name:    "missing parameters",
+			data:    "image: ${IMAGE}\nnamespace: ${NAMESPACE}\nreplicas: ${REPLICAS}",
+			params:  map[string]string{"IMAGE": "busybox"},
+			wantErr: "no value for parameters NAMESPACE, REPLICAS",
+		},
+		{
+			name: "shell variables without braces are kept",
+			data: "command: echo $HOME",
+			want: "command: echo $HOME",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := substituteFixtureParameters([]byte(tt.data), tt.params)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestDecodeFixture(t *testing.T) {
+	data := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: second
+`
+	objects, err := decodeFixture([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].GetKind() != "ConfigMap" || objects[1].GetName() != "second" {
+		t.Errorf("unexpected objects: %v", objects)
+	}
+}
@@ -94,7 +94,7 @@ func WaitForInternalRegistryHostname(oc *CLI) (string, error) {
 			}
 		}()
 	}
-	err := wait.Poll(2*time.Second, 2*time.Minute, func() (bool, error) {
+	err := poll(2*time.Second, 2*time.Minute, false, func() (bool, error) {
 		imageConfig, err := oc.AsAdmin().AdminConfigClient().ConfigV1().Images().Get(ctx, "cluster", metav1.GetOptions{})
 		if err != nil {
 			if kapierrs.IsNotFound(err) {
@@ -297,7 +297,7 @@ func WaitForImageStreamImport(oc *CLI) error {
 		}
 
 		// Wait for imageRegistry to be ready
-		pollErr := wait.PollUntilContextTimeout(ctx, 10*time.Second, 150*time.Second, false, func(context.Context) (bool, error) {
+		pollErr := pollUntilTimeout(ctx, 10*time.Second, 150*time.Second, false, func(context.Context) (bool, error) {
 			return checkNamespaceImageStreamImported(ctx, oc, imageStreamName, registryHostname, oc.Namespace())
 		})
 		// pollErr will be not nil if there was an immediate error, or we timed out.
@@ -362,7 +362,7 @@ func WaitForSamplesImagestream(ctx context.Context, oc *CLI, imagestream string,
 	// Wait up to 150 seconds for an imagestream to import.
 	// Based on a sampling of CI tests, imagestream imports from registry.redhat.io can take up to 2 minutes to complete.
 	// Imports which take longer generally indicate that there is a performance regression or outage in the container registry.
-	pollErr := wait.Poll(10*time.Second, 150*time.Second, func() (bool, error) {
+	pollErr := poll(10*time.Second, 150*time.Second, false, func() (bool, error) {
 		if openshiftSamplesEnabled {
 			retried, err = retrySamplesImagestreamImportIfNeeded(ctx, oc, imagestream)
 			if err != nil {
@@ -827,6 +827,11 @@ type BuildResult struct {
 	BuildTimeout bool
 	// Alternate log dumper function. If set, this is called instead of 'oc logs'
 	LogDumper LogDumperFunc
+	// BuildLogs holds the build logs captured by StartBuildAndWait when the build did not succeed.
+	BuildLogs string
+	// BuilderPodEvents holds the events of the builder pod captured by StartBuildAndWait when
+	// the build did not succeed.
+	BuilderPodEvents []corev1.Event
 	// The openshift client which created this build.
 	Oc *CLI
 }
@@ -861,6 +866,14 @@ func (t *BuildResult) DumpLogs() {
 
 	e2e.Logf("\n\n")
 
+	if len(t.BuilderPodEvents) > 0 {
+		e2e.Logf("\n** Builder Pod Events:\n")
+		for _, event := range t.BuilderPodEvents {
+			e2e.Logf("%s %s %s: %s\n", event.LastTimestamp.Format(time.RFC3339), event.Type, event.Reason, event.Message)
+		}
+		e2e.Logf("\n\n")
+	}
+
 	t.dumpRegistryLogs()
 
 	// if we suspect that we are filling up the registry file system, call ExamineDiskUsage / ExaminePodDiskUsage
@@ -1011,16 +1024,50 @@ func StartBuildResult(oc *CLI, args ...string) (result *BuildResult, err error)
 	return result, nil
 }
 
-// StartBuildAndWait executes OC start-build with the specified arguments on an existing buildconfig.
+// StartBuildAndWait executes OC start-build on the buildConfig with the specified arguments (for example
+// --from-dir, --from-file or --wait) and waits for the build to reach a terminal phase.
 // Note that start-build will be run with "-o=name" as a parameter when using this method.
 // If no error is returned from this method, it means that the build attempted successfully, NOT that
-// the build completed. For completion information, check the BuildResult object.
-func StartBuildAndWait(oc *CLI, args ...string) (result *BuildResult, err error) {
-	result, err = StartBuildResult(oc, args...)
+// the build completed. For completion information, check the BuildResult object. When the build did
+// not succeed, its logs and the events of the builder pod are captured into the result.
+func StartBuildAndWait(oc *CLI, buildConfig string, args ...string) (result *BuildResult, err error) {
+	result, err = StartBuildResult(oc, append([]string{buildConfig}, args...)...)
 	if err != nil {
 		return result, err
 	}
-	return result, WaitForBuildResult(oc.BuildClient().BuildV1().Builds(oc.Namespace()), result)
+	result.BuildConfigName = buildConfig
+	if err := WaitForBuildResult(oc.BuildClient().BuildV1().Builds(oc.Namespace()), result); err != nil {
+		return result, err
+	}
+	if !result.BuildSuccess {
+		result.captureFailureDetails()
+	}
+	return result, nil
+}
+
+// captureFailureDetails records the build logs and the builder pod events into the result.
+func (t *BuildResult) captureFailureDetails() {
+	logs, err := t.Logs()
+	if err != nil {
+		e2e.Logf("Error retrieving logs of build %s: %v", t.BuildName, err)
+	}
+	t.BuildLogs = logs
+
+	if t.Build == nil {
+		return
+	}
+	podName := t.Build.Annotations[buildv1.BuildPodNameAnnotation]
+	if podName == "" {
+		return
+	}
+	events, err := t.Oc.AdminKubeClient().CoreV1().Events(t.Oc.Namespace()).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": podName}.String(),
+	})
+	if err != nil {
+		e2e.Logf("Error retrieving events of builder pod %s: %v", podName, err)
+		return
+	}
+	t.BuilderPodEvents = events.Items
 }
 
 // WaitForBuildResult updates result wit the state of the build
@@ -1074,7 +1121,7 @@ func WaitForABuildWithTimeout(c buildv1clienttyped.BuildInterface, name string,
 	}
 
 	// wait 2 minutes for build to exist
-	err := wait.Poll(1*time.Second, createTimeout, func() (bool, error) {
+	err := poll(1*time.Second, createTimeout, false, func() (bool, error) {
 		if _, err := c.Get(context.Background(), name, metav1.GetOptions{}); err != nil {
 			e2e.Logf("attempt to get buildconfig %s failed with error: %s", name, err.Error())
 			return false, nil
@@ -1088,7 +1135,7 @@ func WaitForABuildWithTimeout(c buildv1clienttyped.BuildInterface, name string,
 		return err
 	}
 	// wait longer for the build to run to completion
-	err = wait.Poll(5*time.Second, completeTimeout, func() (bool, error) {
+	err = poll(5*time.Second, completeTimeout, false, func() (bool, error) {
 		list, err := c.List(context.Background(), metav1.ListOptions{FieldSelector: fields.Set{"metadata.name": name}.AsSelector().String()})
 		if err != nil {
 			e2e.Logf("error listing builds: %v", err)
@@ -1147,7 +1194,7 @@ func WaitForServiceAccount(c corev1client.ServiceAccountInterface, name string)
 		}
 		return true, nil
 	}
-	return wait.Poll(100*time.Millisecond, 3*time.Minute, waitFn)
+	return poll(100*time.Millisecond, 3*time.Minute, false, waitFn)
 }
 
 // WaitForServiceAccountWithSecret waits until the named service account gets fully
@@ -1194,7 +1241,7 @@ func WaitForServiceAccountWithSecret(config configclient.ClusterVersionInterface
 		e2e.Logf("Waiting for service account %q secrets (%s) to include dockercfg ...", name, strings.Join(secretNames, ","))
 		return false, nil
 	}
-	return wait.Poll(100*time.Millisecond, 3*time.Minute, waitFn)
+	return poll(100*time.Millisecond, 3*time.Minute, false, waitFn)
 }
 
 // WaitForNamespaceSCCAnnotations waits up to 30s for the cluster-policy-controller to add the SCC related
@@ -1220,7 +1267,7 @@ func WaitForNamespaceSCCAnnotations(c corev1client.CoreV1Interface, name string)
 		e2e.Logf("namespace %s current annotation set: %#v", name, ns.Annotations)
 		return false, nil
 	}
-	return wait.Poll(time.Duration(250*time.Millisecond), 30*time.Minute, waitFn)
+	return poll(time.Duration(250*time.Millisecond), 30*time.Minute, false, waitFn)
 }
 
 // WaitForAnImageStream waits for an ImageStream to fulfill the isOK function
@@ -1402,7 +1449,7 @@ func GetPodNamesByFilter(c corev1client.PodInterface, label labels.Selector, pre
 }
 
 func WaitForAJob(c batchv1client.JobInterface, name string, timeout time.Duration) error {
-	return wait.Poll(1*time.Second, timeout, func() (bool, error) {
+	return poll(1*time.Second, timeout, false, func() (bool, error) {
 		j, e := c.Get(context.Background(), name, metav1.GetOptions{})
 		if e != nil {
 			return true, e
@@ -1422,7 +1469,7 @@ func WaitForAJob(c batchv1client.JobInterface, name string, timeout time.Duratio
 // satisfy the predicate are found
 func WaitForPods(c corev1client.PodInterface, label labels.Selector, predicate func(corev1.Pod) bool, count int, timeout time.Duration) ([]string, error) {
 	var podNames []string
-	err := wait.Poll(1*time.Second, timeout, func() (bool, error) {
+	err := poll(1*time.Second, timeout, false, func() (bool, error) {
 		p, e := GetPodNamesByFilter(c, label, predicate)
 		if e != nil {
 			return true, e
@@ -1472,7 +1519,7 @@ func CheckPodNoOp(pod corev1.Pod) bool {
 
 // WaitUntilPodIsGone waits until the named Pod will disappear
 func WaitUntilPodIsGone(c corev1client.PodInterface, podName string, timeout time.Duration) error {
-	return wait.Poll(1*time.Second, timeout, func() (bool, error) {
+	return poll(1*time.Second, timeout, false, func() (bool, error) {
 		_, err := c.Get(context.Background(), podName, metav1.GetOptions{})
 		if err != nil {
 			if strings.Contains(err.Error(), "not found") {
@@ -1713,7 +1760,7 @@ func FetchURL(oc *CLI, url string, retryTimeout time.Duration) (string, error) {
 		}
 		return true, nil
 	}
-	pollErr := wait.Poll(time.Duration(1*time.Second), retryTimeout, waitFn)
+	pollErr := poll(time.Duration(1*time.Second), retryTimeout, false, waitFn)
 	if pollErr == wait.ErrWaitTimeout {
 		return "", fmt.Errorf("Timed out while fetching url %q", url)
 	}
@@ -1805,7 +1852,7 @@ func GetEndpointAddress(oc *CLI, name string) (string, error) {
 func CheckForBuildEvent(client corev1client.CoreV1Interface, build *buildv1.Build, reason, message string) {
 	scheme, _ := apitesting.SchemeForOrDie(buildv1.Install)
 	var expectedEvent *corev1.Event
-	err := wait.PollImmediate(e2e.Poll, 1*time.Minute, func() (bool, error) {
+	err := poll(e2e.Poll, 1*time.Minute, true, func() (bool, error) {
 		events, err := client.Events(build.Namespace).Search(scheme, build)
 		if err != nil {
 			return false, err
@@ -1847,7 +1894,7 @@ func NewPodExecutor(oc *CLI, name, image string) (*PodExecutor, error) {
 // command took longer then 3 minutes to run.
 func (r *PodExecutor) Exec(script string) (string, error) {
 	var out string
-	waitErr := wait.PollImmediate(1*time.Second, 3*time.Minute, func() (bool, error) {
+	waitErr := poll(1*time.Second, 3*time.Minute, true, func() (bool, error) {
 		var err error
 		out, err = r.client.Run("exec").Args(r.podName, "--", "/bin/bash", "-c", script).Output()
 		return true, err
@@ -1883,7 +1930,7 @@ func RunOneShotCommandPod(
 	}
 
 	// Wait for command completion.
-	err = wait.PollImmediate(1*time.Second, timeout, func() (done bool, err error) {
+	err = poll(1*time.Second, timeout, true, func() (done bool, err error) {
 		cmdPod, getErr := oc.AdminKubeClient().CoreV1().Pods(oc.Namespace()).Get(context.Background(), pod.Name, metav1.GetOptions{})
 		if getErr != nil {
 			e2e.Logf("failed to get pod %q: %v", pod.Name, err)
@@ -1901,7 +1948,7 @@ func RunOneShotCommandPod(
 	}
 
 	// Gather pod log output
-	err = wait.PollImmediate(1*time.Second, timeout, func() (done bool, err error) {
+	err = poll(1*time.Second, timeout, true, func() (done bool, err error) {
 		logs, logErr := getPodLogs(oc, pod)
 		if logErr != nil {
 			return false, logErr
@@ -2034,7 +2081,7 @@ func WaitForUserBeAuthorized(oc *CLI, user string, attributes *authorizationapi.
 			User:               user,
 		},
 	}
-	return wait.PollImmediate(1*time.Second, 1*time.Minute, func() (bool, error) {
+	return poll(1*time.Second, 1*time.Minute, true, func() (bool, error) {
 		e2e.Logf("Waiting for user '%s' to be authorized for %v in ns '%s'", user, attributes, oc.Namespace())
 		resp, err := oc.AdminKubeClient().AuthorizationV1().SubjectAccessReviews().Create(context.Background(), sar, metav1.CreateOptions{})
 		if err == nil && resp != nil && resp.Status.Allowed {
@@ -2247,6 +2294,25 @@ func DoesApiResourceExist(config *rest.Config, apiResourceName, group string) (b
 	return false, nil
 }
 
+// IsOpenShift returns true if the cluster serves the config.openshift.io API group,
+// which tells an OpenShift cluster from a vanilla Kubernetes one.
+func (c *CLI) IsOpenShift() (bool, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(c.AdminConfig())
+	if err != nil {
+		return false, err
+	}
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups.Groups {
+		if group.Name == configv1.GroupName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func IsNamespaceExist(kubeClient *kubernetes.Clientset, namespace string) (bool, error) {
 	_, err := kubeClient.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
 	if err != nil {
@@ -2282,15 +2348,40 @@ func IsManagedServiceCluster(ctx context.Context, adminClient kubernetes.Interfa
 	return false, nil
 }
 
+// IsSingleNode returns true if the control plane topology of the cluster is SingleReplica,
+// i.e. the cluster is single-node OpenShift. The infrastructure topology is not considered.
+// False is returned if the Infrastructure cannot be read.
 func IsSingleNode(ctx context.Context, configClient clientconfigv1.Interface) (bool, error) {
-	infrastructure, err := configClient.ConfigV1().Infrastructures().Get(ctx, "cluster", metav1.GetOptions{})
+	singleNode, err := isSingleReplicaControlPlane(ctx, configClient)
 	if err != nil {
 		return false, nil
 	}
+	return singleNode, nil
+}
+
+// IsSingleNode returns true if the control plane topology of the cluster is SingleReplica, as
+// the package level IsSingleNode does, but returns an error if the Infrastructure cannot be read.
+func (c *CLI) IsSingleNode() (bool, error) {
+	return isSingleReplicaControlPlane(context.Background(), c.AdminConfigClient())
+}
 
+func isSingleReplicaControlPlane(ctx context.Context, configClient clientconfigv1.Interface) (bool, error) {
+	infrastructure, err := configClient.ConfigV1().Infrastructures().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failure getting test cluster Infrastructure: %w", err)
+	}
 	return infrastructure.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode, nil
 }
 
+// SkipUnlessMultiNode skips the test on single-node clusters.
+func (c *CLI) SkipUnlessMultiNode() {
+	singleNode, err := c.IsSingleNode()
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred())
+	if singleNode {
+		skipper.Skipf("Test requires a multi-node cluster")
+	}
+}
+
 func IsHypershift(ctx context.Context, configClient clientconfigv1.Interface) (bool, error) {
 	infrastructure, err := configClient.ConfigV1().Infrastructures().Get(ctx, "cluster", metav1.GetOptions{})
 	if err != nil {
@@ -2321,6 +2412,32 @@ func IsMicroShiftCluster(kubeClient k8sclient.Interface) (bool, error) {
 	return true, nil
 }
 
+// IsMicroShift returns true if the cluster lacks the project.openshift.io API group
+// and carries the microshift-version configmap. Errors are logged and treated as
+// not MicroShift.
+func IsMicroShift(oc *CLI) bool {
+	projectsExist, err := DoesApiResourceExist(oc.AdminConfig(), "projects", "project.openshift.io")
+	if err != nil {
+		e2e.Logf("unable to discover the project.openshift.io API group: %v", err)
+		return false
+	}
+	if projectsExist {
+		return false
+	}
+	isMicroShift, err := IsMicroShiftCluster(oc.AdminKubeClient())
+	if err != nil {
+		return false
+	}
+	return isMicroShift
+}
+
+// SkipIfMicroShift skips the test if the cluster is MicroShift.
+func (c *CLI) SkipIfMicroShift(reason string) {
+	if IsMicroShift(c) {
+		skipper.Skipf("Skipping on MicroShift: %s", reason)
+	}
+}
+
 func groupName(groupVersionName string) string {
 	return strings.Split(groupVersionName, "/")[0]
 }
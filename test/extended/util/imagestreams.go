@@ -0,0 +1,76 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+// WaitForImageStreamTag waits until the latest generation of the tag in the image stream has been
+// imported or pushed, and returns the resulting tag event, which holds the resolved image reference
+// and digest. It fails early with the import failure message when the import of that generation failed.
+func WaitForImageStreamTag(oc *CLI, ns, stream, tag string, timeout time.Duration) (*imagev1.TagEvent, error) {
+	var event *imagev1.TagEvent
+	var status string
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		is, err := oc.AdminImageClient().ImageV1().ImageStreams(ns).Get(ctx, stream, metav1.GetOptions{})
+		if err != nil {
+			status = err.Error()
+			return false, nil
+		}
+		event, status, err = imageStreamTagResolved(is, tag)
+		return event != nil, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tag %s/%s:%s was not resolved: %w (%s)", ns, stream, tag, err, status)
+	}
+	return event, nil
+}
+
+// LatestImageFor returns the image reference the image stream tag, in the form stream:tag,
+// currently resolves to, waiting up to 5 minutes for it to be imported.
+func LatestImageFor(oc *CLI, ns, istag string) (string, error) {
+	stream, tag, ok := strings.Cut(istag, ":")
+	if !ok {
+		tag = "latest"
+	}
+	event, err := WaitForImageStreamTag(oc, ns, stream, tag, 5*time.Minute)
+	if err != nil {
+		return "", err
+	}
+	return event.DockerImageReference, nil
+}
+
+// imageStreamTagResolved returns the latest tag event of the tag once the generation of its spec
+// tag has been observed, or an error when the import of that generation failed.
+func imageStreamTagResolved(is *imagev1.ImageStream, tag string) (*imagev1.TagEvent, string, error) {
+	var generation int64
+	for _, specTag := range is.Spec.Tags {
+		if specTag.Name == tag && specTag.Generation != nil {
+			generation = *specTag.Generation
+		}
+	}
+	for _, statusTag := range is.Status.Tags {
+		if statusTag.Tag != tag {
+			continue
+		}
+		for _, condition := range statusTag.Conditions {
+			if condition.Type == imagev1.ImportSuccess && condition.Status == "False" && condition.Generation >= generation {
+				return nil, "", fmt.Errorf("import failed: %s: %s", condition.Reason, condition.Message)
+			}
+		}
+		if len(statusTag.Items) == 0 {
+			return nil, "no image has been imported yet", nil
+		}
+		if statusTag.Items[0].Generation < generation {
+			return nil, fmt.Sprintf("generation %d has not been imported yet, latest is %d", generation, statusTag.Items[0].Generation), nil
+		}
+		return &statusTag.Items[0], "", nil
+	}
+	return nil, "the tag is not in the image stream status yet", nil
+}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"testing"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImageStreamTagResolved(t *testing.T) {
+	generation := func(g int64) *int64 { return &g }
+	specTag := func(g int64) []imagev1.TagReference {
+		return []imagev1.TagReference{{Name: "latest", Generation: generation(g)}}
+	}
+
+	tests := []struct {
+		name      string
+		is        imagev1.ImageStream
+		wantImage string
+		wantErr   bool
+	}{
+		{
+			name: "tag not in status",
+			is:   imagev1.ImageStream{Spec: imagev1.ImageStreamSpec{Tags: specTag(1)}},
+		},
+		{
+			name: "latest generation imported",
+			is: imagev1.ImageStream{
+				Spec: imagev1.ImageStreamSpec{Tags: specTag(2)},
+				Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{
+					Tag:   "latest",
+					Items: []imagev1.TagEvent{{DockerImageReference: "quay.io/a/b@sha256:2", Generation: 2}, {DockerImageReference: "quay.io/a/b@sha256:1", Generation: 1}},
+				}}},
+			},
+			wantImage: "quay.io/a/b@sha256:2",
+		},
+		{
+			name: "older generation only",
+			is: imagev1.ImageStream{
+				Spec: imagev1.ImageStreamSpec{Tags: specTag(2)},
+				Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{
+					Tag:   "latest",
+					Items: []imagev1.TagEvent{{DockerImageReference: "quay.io/a/b@sha256:1", Generation: 1}},
+				}}},
+			},
+		},
+		{
+			name: "import of the latest generation failed",
+			is: imagev1.ImageStream{
+				Spec: imagev1.ImageStreamSpec{Tags: specTag(2)},
+				Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{
+					Tag:        "latest",
+					Items:      []imagev1.TagEvent{{DockerImageReference: "quay.io/a/b@sha256:1", Generation: 1}},
+					Conditions: []imagev1.TagEventCondition{{Type: imagev1.ImportSuccess, Status: corev1.ConditionFalse, Message: "unreachable", Generation: 2}},
+				}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "earlier import failure is ignored",
+			is: imagev1.ImageStream{
+				Spec: imagev1.ImageStreamSpec{Tags: specTag(3)},
+				Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{
+					Tag:        "latest",
+					Items:      []imagev1.TagEvent{{DockerImageReference: "quay.io/a/b@sha256:3", Generation: 3}},
+					Conditions: []imagev1.TagEventCondition{{Type: imagev1.ImportSuccess, Status: corev1.ConditionFalse, Message: "unreachable", Generation: 2}},
+				}}},
+			},
+			wantImage: "quay.io/a/b@sha256:3",
+		},
+		{
+			name: "pushed tag without spec",
+			is: imagev1.ImageStream{
+				Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{
+					Tag:   "latest",
+					Items: []imagev1.TagEvent{{DockerImageReference: "image-registry/ns/b@sha256:1", Generation: 1}},
+				}}},
+			},
+			wantImage: "image-registry/ns/b@sha256:1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, _, err := imageStreamTagResolved(&tt.is, "latest")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var image string
+			if event != nil {
+				image = event.DockerImageReference
+			}
+			if image != tt.wantImage {
+				t.Errorf("expected image %q, got %q", tt.wantImage, image)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// WaitForCronJobRun waits for the named CronJob to create a Job after since and returns it.
+// On timeout the returned error lists the Jobs that exist in the namespace.
+func (c *CLI) WaitForCronJobRun(namespace, name string, since time.Time, timeout time.Duration) (*batchv1.Job, error) {
+	client := c.AdminKubeClient().BatchV1().Jobs(namespace)
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Watch(ctx, options)
+		},
+	}
+
+	// creation timestamps only have second granularity
+	since = since.Truncate(time.Second)
+	var job *batchv1.Job
+	_, err := watchtools.UntilWithSync(ctx, lw, &batchv1.Job{}, nil, func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			j := event.Object.(*batchv1.Job)
+			if isJobOwnedByCronJob(j, name) && !j.CreationTimestamp.Time.Before(since) {
+				job = j
+				return true, nil
+			}
+			return false, nil
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("timed out waiting for cronjob %s/%s to create a job after %s: %w (existing jobs: %s)",
+			namespace, name, since.Format(time.RFC3339), err, describeJobs(c, namespace))
+	}
+	e2e.Logf("CronJob %s/%s created job %q", namespace, name, job.Name)
+	return job, nil
+}
+
+// isJobOwnedByCronJob returns true if the job has a CronJob owner reference with the given name.
+func isJobOwnedByCronJob(job *batchv1.Job, cronJobName string) bool {
+	for _, ref := range job.OwnerReferences {
+		if ref.Kind == "CronJob" && ref.Name == cronJobName {
+			return true
+		}
+	}
+	return false
+}
+
+// describeJobs returns a short summary of the jobs in the namespace for use in error messages.
+func describeJobs(c *CLI, namespace string) string {
+	jobs, err := c.AdminKubeClient().BatchV1().Jobs(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Sprintf("<unable to list jobs: %v>", err)
+	}
+	if len(jobs.Items) == 0 {
+		return "<none>"
+	}
+	var summary []string
+	for _, j := range jobs.Items {
+		owner := "<none>"
+		if ref := metav1.GetControllerOf(&j); ref != nil {
+			owner = ref.Kind + "/" + ref.Name
+		}
+		summary = append(summary, fmt.Sprintf("%s (owner=%s, created=%s)", j.Name, owner, j.CreationTimestamp.Format(time.RFC3339)))
+	}
+	return strings.Join(summary, ", ")
+}
+
+// WaitForJobComplete waits for the Job to have a Complete or Failed condition. A Failed Job
+// results in an error that includes the logs of the Job's pods.
+func WaitForJobComplete(oc *CLI, ns, name string, timeout time.Duration) error {
+	_, finished, err := waitForJobFinished(oc, ns, name, timeout)
+	if err != nil {
+		return err
+	}
+	if finished.Type == batchv1.JobFailed {
+		return fmt.Errorf("job %s/%s failed: %s: %s\n%s", ns, name, finished.Reason, finished.Message, jobPodLogs(oc, ns, name))
+	}
+	e2e.Logf("Job %s/%s completed", ns, name)
+	return nil
+}
+
+// JobPodTermination describes why a container of a failed pod of a Job terminated, or why the
+// pod itself failed, e.g. with DeadlineExceeded, when ContainerName is empty.
+type JobPodTermination struct {
+	PodName       string
+	ContainerName string
+	ExitCode      int32
+	Reason        string
+	Message       string
+}
+
+func (t JobPodTermination) String() string {
+	if len(t.ContainerName) == 0 {
+		return fmt.Sprintf("pod %s: %s: %s", t.PodName, t.Reason, t.Message)
+	}
+	return fmt.Sprintf("pod %s container %s: exit code %d, reason %s: %s", t.PodName, t.ContainerName, t.ExitCode, t.Reason, t.Message)
+}
+
+// WaitForJobFailed waits for the Job to have a Failed condition and returns it, for tests that
+// expect the Job to fail, along with why its failed pods terminated. A Job that completes
+// successfully is an error.
+func (c *CLI) WaitForJobFailed(namespace, name string, timeout time.Duration) (*batchv1.Job, []JobPodTermination, error) {
+	job, finished, err := waitForJobFinished(c, namespace, name, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if finished.Type != batchv1.JobFailed {
+		return job, nil, fmt.Errorf("job %s/%s completed but was expected to fail", namespace, name)
+	}
+	pods, err := c.AdminKubeClient().CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: "job-name=" + name})
+	if err != nil {
+		return job, nil, fmt.Errorf("unable to list the pods of job %s/%s: %w", namespace, name, err)
+	}
+	terminations := jobPodTerminations(pods.Items)
+	var reasons []string
+	for _, termination := range terminations {
+		reasons = append(reasons, termination.String())
+	}
+	e2e.Logf("Job %s/%s failed as expected: %s: %s\n%s", namespace, name, finished.Reason, finished.Message, strings.Join(reasons, "\n"))
+	return job, terminations, nil
+}
+
+// waitForJobFinished waits for the Job to have a true Complete or Failed condition and returns
+// the Job with that condition.
+func waitForJobFinished(oc *CLI, ns, name string, timeout time.Duration) (*batchv1.Job, *batchv1.JobCondition, error) {
+	client := oc.AdminKubeClient().BatchV1().Jobs(ns)
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return client.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return client.Watch(ctx, options)
+		},
+	}
+
+	var job *batchv1.Job
+	var finished *batchv1.JobCondition
+	_, err := watchtools.UntilWithSync(ctx, lw, &batchv1.Job{}, nil, func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			job = event.Object.(*batchv1.Job)
+			for i, condition := range job.Status.Conditions {
+				if condition.Status != corev1.ConditionTrue {
+					continue
+				}
+				switch condition.Type {
+				case batchv1.JobComplete, batchv1.JobFailed:
+					finished = &job.Status.Conditions[i]
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("timed out waiting for job %s/%s to finish: %w (%s)", ns, name, err, describeJobs(oc, ns))
+	}
+	return job, finished, nil
+}
+
+// TriggerCronJobAndWait creates a Job from the template of the CronJob, like
+// `oc create job --from=cronjob/<name>`, waits for it to finish and deletes it.
+// The Job is also registered for deletion in case the test fails before that.
+func TriggerCronJobAndWait(oc *CLI, ns, cronjob string, timeout time.Duration) error {
+	cj, err := oc.AdminKubeClient().BatchV1().CronJobs(ns).Get(context.Background(), cronjob, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-manual-%s", cronjob, rand.String(5)),
+			Namespace:   ns,
+			Labels:      cj.Spec.JobTemplate.Labels,
+			Annotations: map[string]string{"cronjob.kubernetes.io/instantiate": "manual"},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cj, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: cj.Spec.JobTemplate.Spec,
+	}
+	for k, v := range cj.Spec.JobTemplate.Annotations {
+		job.Annotations[k] = v
+	}
+	job, err = oc.AdminKubeClient().BatchV1().Jobs(ns).Create(context.Background(), job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create a job from cronjob %s/%s: %w", ns, cronjob, err)
+	}
+	oc.AddResourceToDelete(batchv1.SchemeGroupVersion.WithResource("jobs"), job)
+
+	if err := WaitForJobComplete(oc, ns, job.Name, timeout); err != nil {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	return oc.AdminKubeClient().BatchV1().Jobs(ns).Delete(context.Background(), job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+}
+
+// jobPodLogs returns the logs of every container of the pods of the Job for use in error messages.
+func jobPodLogs(oc *CLI, ns, jobName string) string {
+	pods, err := oc.AdminKubeClient().CoreV1().Pods(ns).List(context.Background(), metav1.ListOptions{LabelSelector: "job-name=" + jobName})
+	if err != nil {
+		return fmt.Sprintf("<unable to list pods: %v>", err)
+	}
+	var out strings.Builder
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			logs, err := PodLogs(oc, ns, pod.Name, container.Name, PodLogOptions{})
+			if err != nil {
+				logs = fmt.Sprintf("<unable to get logs: %v>", err)
+			}
+			fmt.Fprintf(&out, "logs of %s/%s (%s):\n%s\n", pod.Name, container.Name, describePod(&pod), logs)
+		}
+	}
+	return out.String()
+}
+
+// jobPodTerminations returns why the failed pods and their containers that exited with an error
+// terminated.
+func jobPodTerminations(pods []corev1.Pod) []JobPodTermination {
+	var terminations []JobPodTermination
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		if len(pod.Status.Reason) > 0 {
+			terminations = append(terminations, JobPodTermination{PodName: pod.Name, Reason: pod.Status.Reason, Message: pod.Status.Message})
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+				terminations = append(terminations, JobPodTermination{
+					PodName:       pod.Name,
+					ContainerName: status.Name,
+					ExitCode:      terminated.ExitCode,
+					Reason:        terminated.Reason,
+					Message:       strings.TrimSpace(terminated.Message),
+				})
+			}
+		}
+	}
+	return terminations
+}
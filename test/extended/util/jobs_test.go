@@ -0,0 +1,56 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestJobPodTerminations(t *testing.T) {
+	terminated := func(name string, exitCode int32, reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: reason, Message: "  out of memory\n"}},
+		}
+	}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "succeeded"},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodSucceeded,
+				ContainerStatuses: []corev1.ContainerStatus{terminated("main", 0, "Completed")},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "oom"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					terminated("main", 137, "OOMKilled"),
+					terminated("sidecar", 0, "Completed"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deadline"},
+			Status: corev1.PodStatus{
+				Phase:   corev1.PodFailed,
+				Reason:  "DeadlineExceeded",
+				Message: "Pod was active on the node longer than the specified deadline",
+			},
+		},
+	}
+
+	expected := []JobPodTermination{
+		{PodName: "oom", ContainerName: "main", ExitCode: 137, Reason: "OOMKilled", Message: "out of memory"},
+		{PodName: "deadline", Reason: "DeadlineExceeded", Message: "Pod was active on the node longer than the specified deadline"},
+	}
+	if actual := jobPodTerminations(pods); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	if actual := expected[0].String(); actual != "pod oom container main: exit code 137, reason OOMKilled: out of memory" {
+		t.Errorf("unexpected description %q", actual)
+	}
+}
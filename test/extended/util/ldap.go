@@ -14,7 +14,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/apimachinery/pkg/util/wait"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 
 	"github.com/openshift/library-go/pkg/crypto"
@@ -141,7 +140,7 @@ func CreateLDAPTestServer(oc *CLI) (svcNs, svcName, svcHostname string, caPem []
 	}
 
 	// Wait for an available replica.
-	err = wait.PollImmediate(1*time.Second, 5*time.Minute, func() (done bool, err error) {
+	err = poll(1*time.Second, 5*time.Minute, true, func() (done bool, err error) {
 		dep, getErr := oc.AdminKubeClient().AppsV1().Deployments(oc.Namespace()).Get(context.Background(), serverDeployment.Name,
 			v1.GetOptions{})
 		if getErr != nil {
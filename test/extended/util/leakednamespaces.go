@@ -0,0 +1,127 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// testNamespacePrefix is the prefix of the namespaces created by SetupProject.
+const testNamespacePrefix = "e2e-test-"
+
+// ForceCleanLeakedTestNamespaces makes ReportLeakedTestNamespaces delete the leaked namespaces and
+// finalize the ones stuck in Terminating. It is off unless FORCE_CLEAN_LEAKED_TEST_NAMESPACES is
+// true, since finalizing a namespace orphans whatever its finalizers were waiting for.
+var ForceCleanLeakedTestNamespaces = os.Getenv("FORCE_CLEAN_LEAKED_TEST_NAMESPACES") == "true"
+
+// ReportLeakedTestNamespaces returns the names of the e2e test namespaces created more than
+// olderThan ago, or being deleted for more than olderThan, and logs their finalizers and deletion
+// conditions. When ForceCleanLeakedTestNamespaces is set they are also deleted, and the spec
+// finalizers of those stuck in Terminating are removed.
+func ReportLeakedTestNamespaces(adminClient kubernetes.Interface, olderThan time.Duration) ([]string, error) {
+	namespaces, err := adminClient.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var leaked []string
+	for _, ns := range leakedTestNamespaces(namespaces.Items, olderThan, waitClock.Now()) {
+		framework.Logf("Leaked test namespace %s", describeLeakedNamespace(&ns))
+		leaked = append(leaked, ns.Name)
+		if ForceCleanLeakedTestNamespaces {
+			forceCleanNamespace(adminClient, &ns)
+		}
+	}
+	return leaked, nil
+}
+
+// ReportLeakedTestNamespacesAfterSuite reports the leaked test namespaces with the admin
+// kubeconfig, for suites to call from their AfterSuite:
+//
+//	g.AfterSuite(func() { exutil.ReportLeakedTestNamespacesAfterSuite(30 * time.Minute) })
+//
+// Failures are logged rather than failing the suite.
+func ReportLeakedTestNamespacesAfterSuite(olderThan time.Duration) {
+	config, err := GetClientConfig(KubeConfigPath())
+	if err != nil {
+		framework.Logf("Unable to report leaked test namespaces: %v", err)
+		return
+	}
+	adminClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		framework.Logf("Unable to report leaked test namespaces: %v", err)
+		return
+	}
+	leaked, err := ReportLeakedTestNamespaces(adminClient, olderThan)
+	if err != nil {
+		framework.Logf("Unable to report leaked test namespaces: %v", err)
+		return
+	}
+	if len(leaked) > 0 {
+		framework.Logf("%d test namespaces leaked: %s", len(leaked), strings.Join(leaked, ", "))
+	}
+}
+
+// leakedTestNamespaces returns the test namespaces that were created, or have been deleting, for
+// more than olderThan at now, sorted by name.
+func leakedTestNamespaces(namespaces []corev1.Namespace, olderThan time.Duration, now time.Time) []corev1.Namespace {
+	var leaked []corev1.Namespace
+	for _, ns := range namespaces {
+		if !strings.HasPrefix(ns.Name, testNamespacePrefix) {
+			continue
+		}
+		since := ns.CreationTimestamp.Time
+		if ns.DeletionTimestamp != nil {
+			since = ns.DeletionTimestamp.Time
+		}
+		if now.Sub(since) > olderThan {
+			leaked = append(leaked, ns)
+		}
+	}
+	sort.Slice(leaked, func(i, j int) bool { return leaked[i].Name < leaked[j].Name })
+	return leaked
+}
+
+// describeLeakedNamespace returns the age or deletion time of the namespace along with its
+// finalizers and the conditions explaining why its deletion is not complete.
+func describeLeakedNamespace(ns *corev1.Namespace) string {
+	var b strings.Builder
+	if ns.DeletionTimestamp != nil {
+		fmt.Fprintf(&b, "%s: deleting since %s", ns.Name, ns.DeletionTimestamp.UTC().Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(&b, "%s: created at %s", ns.Name, ns.CreationTimestamp.UTC().Format(time.RFC3339))
+	}
+	if len(ns.Finalizers) > 0 {
+		fmt.Fprintf(&b, ", finalizers %v", ns.Finalizers)
+	}
+	if len(ns.Spec.Finalizers) > 0 {
+		fmt.Fprintf(&b, ", spec finalizers %v", ns.Spec.Finalizers)
+	}
+	for _, condition := range ns.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			fmt.Fprintf(&b, ", %s: %s", condition.Type, condition.Message)
+		}
+	}
+	return b.String()
+}
+
+// forceCleanNamespace deletes the namespace, or removes its spec finalizers when it is already
+// being deleted.
+func forceCleanNamespace(adminClient kubernetes.Interface, ns *corev1.Namespace) {
+	if ns.DeletionTimestamp == nil {
+		err := adminClient.CoreV1().Namespaces().Delete(context.Background(), ns.Name, metav1.DeleteOptions{})
+		framework.Logf("Deleted leaked test namespace %s, err: %v", ns.Name, err)
+		return
+	}
+	finalized := ns.DeepCopy()
+	finalized.Spec.Finalizers = nil
+	_, err := adminClient.CoreV1().Namespaces().Finalize(context.Background(), finalized, metav1.UpdateOptions{})
+	framework.Logf("Finalized leaked test namespace %s, err: %v", ns.Name, err)
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestReportLeakedTestNamespaces(t *testing.T) {
+	fakeClock := withFakeWaitClock(t)
+	now := fakeClock.Now()
+	namespace := func(name string, age time.Duration, deletingFor time.Duration) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+		if deletingFor > 0 {
+			ns.DeletionTimestamp = &metav1.Time{Time: now.Add(-deletingFor)}
+			ns.Spec.Finalizers = []corev1.FinalizerName{corev1.FinalizerKubernetes}
+			ns.Status.Conditions = []corev1.NamespaceCondition{{Type: corev1.NamespaceContentRemaining, Status: corev1.ConditionTrue, Message: "Some resources are remaining: pods. has 1 resource instances"}}
+		}
+		return ns
+	}
+	client := fake.NewSimpleClientset(
+		namespace("e2e-test-old-abcde", 2*time.Hour, 0),
+		namespace("e2e-test-recent-abcde", time.Minute, 0),
+		namespace("e2e-test-stuck-abcde", 2*time.Hour, time.Hour),
+		namespace("e2e-test-deleting-abcde", 2*time.Hour, time.Minute),
+		namespace("openshift-etcd", 48*time.Hour, 0),
+	)
+
+	leaked, err := ReportLeakedTestNamespaces(client, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(leaked, []string{"e2e-test-old-abcde", "e2e-test-stuck-abcde"}) {
+		t.Errorf("unexpected leaked namespaces %v", leaked)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "list" {
+			t.Errorf("unexpected %s without force cleaning", action.GetVerb())
+		}
+	}
+
+	if description := describeLeakedNamespace(namespace("e2e-test-stuck-abcde", 2*time.Hour, time.Hour)); !strings.Contains(description, "spec finalizers [kubernetes]") || !strings.Contains(description, "pods") {
+		t.Errorf("expected the finalizers and remaining content in %q", description)
+	}
+
+	ForceCleanLeakedTestNamespaces = true
+	defer func() { ForceCleanLeakedTestNamespaces = false }()
+	client.ClearActions()
+	if _, err := ReportLeakedTestNamespaces(client, 30*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var cleaned []string
+	for _, action := range client.Actions() {
+		switch action := action.(type) {
+		case clienttesting.DeleteAction:
+			cleaned = append(cleaned, "delete "+action.GetName())
+		case clienttesting.UpdateAction:
+			if action.GetSubresource() == "finalize" {
+				cleaned = append(cleaned, "finalize "+action.GetObject().(*corev1.Namespace).Name)
+			}
+		}
+	}
+	if !reflect.DeepEqual(cleaned, []string{"delete e2e-test-old-abcde", "finalize e2e-test-stuck-abcde"}) {
+		t.Errorf("unexpected cleaning %v", cleaned)
+	}
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kapierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// LeaderHolder returns the holder identity of the leader election Lease, which is empty when the
+// lease is not held.
+func (c *CLI) LeaderHolder(namespace, leaseName string) (string, error) {
+	lease, err := c.AdminKubeClient().CoordinationV1().Leases(namespace).Get(context.Background(), leaseName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if lease.Spec.HolderIdentity == nil {
+		return "", nil
+	}
+	return *lease.Spec.HolderIdentity, nil
+}
+
+// WaitForLeaderChange waits for the leader election Lease to be held by another holder than
+// previousHolder and returns the new holder. A lease that is released but not acquired again is
+// not a change.
+func (c *CLI) WaitForLeaderChange(namespace, leaseName, previousHolder string, timeout time.Duration) (string, error) {
+	var holder string
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		current, err := c.LeaderHolder(namespace, leaseName)
+		if kapierrs.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			e2e.Logf("Unable to get lease %s/%s: %v", namespace, leaseName, err)
+			return false, nil
+		}
+		holder = current
+		return len(holder) > 0 && holder != previousHolder, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("lease %s/%s is still held by %q instead of another holder than %q: %w", namespace, leaseName, holder, previousHolder, err)
+	}
+	e2e.Logf("Lease %s/%s moved from %q to %q", namespace, leaseName, previousHolder, holder)
+	return holder, nil
+}
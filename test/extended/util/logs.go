@@ -0,0 +1,88 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// PodLogOptions selects the part of the container logs returned by PodLogs.
+type PodLogOptions struct {
+	// SinceTime only returns the lines logged after the time, if set
+	SinceTime time.Time
+	// TailLines only returns the last lines, if set
+	TailLines *int64
+	// Previous returns the logs of the previous instance of a restarted container
+	Previous bool
+}
+
+// PodLogs returns the logs of the container of the pod.
+func PodLogs(oc *CLI, ns, pod, container string, opts PodLogOptions) (string, error) {
+	logOptions := &corev1.PodLogOptions{
+		Container: container,
+		TailLines: opts.TailLines,
+		Previous:  opts.Previous,
+	}
+	if !opts.SinceTime.IsZero() {
+		sinceTime := metav1.NewTime(opts.SinceTime)
+		logOptions.SinceTime = &sinceTime
+	}
+	logs, err := oc.AdminKubeClient().CoreV1().Pods(ns).GetLogs(pod, logOptions).DoRaw(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("unable to get the logs of %s/%s container %q: %w", ns, pod, container, err)
+	}
+	return string(logs), nil
+}
+
+// WaitForLogLine follows the logs of the container of the pod and returns the first line
+// matching the regular expression. If the log stream ends, e.g. because the container
+// restarted, it is reopened once.
+func WaitForLogLine(oc *CLI, ns, pod, container string, re *regexp.Regexp, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	const maxReconnects = 1
+	for attempt := 0; ; attempt++ {
+		line, err := followLogsForLine(ctx, oc, ns, pod, container, re)
+		switch {
+		case err == nil:
+			return line, nil
+		case ctx.Err() != nil:
+			return "", fmt.Errorf("timed out waiting for a line matching %q in the logs of %s/%s container %q", re.String(), ns, pod, container)
+		case err != io.EOF || attempt >= maxReconnects:
+			return "", fmt.Errorf("no line matching %q in the logs of %s/%s container %q: %w", re.String(), ns, pod, container, err)
+		}
+		e2e.Logf("Log stream of %s/%s container %q ended, reconnecting", ns, pod, container)
+	}
+}
+
+// followLogsForLine returns the first matching line of the followed logs, or io.EOF if
+// the stream ended without one.
+func followLogsForLine(ctx context.Context, oc *CLI, ns, pod, container string, re *regexp.Regexp) (string, error) {
+	stream, err := oc.AdminKubeClient().CoreV1().Pods(ns).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		if line := scanner.Text(); re.MatchString(line) {
+			return line, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	mcv1 "github.com/openshift/api/machineconfiguration/v1"
+)
+
+// WaitForMCPUpdated waits for the MachineConfigPool to be Updated=True, Updating=False and
+// Degraded=False with all of its machines updated and ready. Rolling a pool reboots every node,
+// so the wait also ends when ctx is cancelled. On timeout the returned error reports the status
+// of each condition and the number of unavailable machines.
+func (c *CLI) WaitForMCPUpdated(ctx context.Context, pool string, timeout time.Duration) error {
+	var status string
+	err := pollUntilTimeout(ctx, 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		mcp, err := c.MachineConfigurationClient().MachineconfigurationV1().MachineConfigPools().Get(ctx, pool, metav1.GetOptions{})
+		if err != nil {
+			status = err.Error()
+			return false, nil
+		}
+		var done bool
+		done, status = machineConfigPoolUpdated(mcp)
+		return done, nil
+	})
+	if err != nil {
+		return fmt.Errorf("machineconfigpool %s was not updated: %w (%s)", pool, err, status)
+	}
+	e2e.Logf("MachineConfigPool %s is updated", pool)
+	return nil
+}
+
+// machineConfigPoolUpdated returns true once the pool has rolled out its current configuration
+// to every machine, with a summary of its status.
+func machineConfigPoolUpdated(mcp *mcv1.MachineConfigPool) (bool, string) {
+	done := mcp.Status.ObservedGeneration >= mcp.Generation
+	var conditions []string
+	for _, expected := range []struct {
+		conditionType mcv1.MachineConfigPoolConditionType
+		status        string
+	}{
+		{mcv1.MachineConfigPoolUpdated, "True"},
+		{mcv1.MachineConfigPoolUpdating, "False"},
+		{mcv1.MachineConfigPoolDegraded, "False"},
+	} {
+		actual := "Unknown"
+		message := ""
+		for _, condition := range mcp.Status.Conditions {
+			if condition.Type == expected.conditionType {
+				actual = string(condition.Status)
+				message = condition.Message
+			}
+		}
+		if actual != expected.status {
+			done = false
+		}
+		if len(message) > 0 {
+			conditions = append(conditions, fmt.Sprintf("%s=%s (%s)", expected.conditionType, actual, message))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s=%s", expected.conditionType, actual))
+		}
+	}
+	status := mcp.Status
+	if status.UpdatedMachineCount != status.MachineCount || status.ReadyMachineCount != status.MachineCount {
+		done = false
+	}
+	return done, fmt.Sprintf("%s; machines=%d updated=%d ready=%d unavailable=%d degraded=%d",
+		strings.Join(conditions, ", "), status.MachineCount, status.UpdatedMachineCount, status.ReadyMachineCount,
+		status.UnavailableMachineCount, status.DegradedMachineCount)
+}
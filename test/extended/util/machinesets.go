@@ -0,0 +1,210 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	kapierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	machineclient "github.com/openshift/client-go/machine/clientset/versioned"
+	machinev1beta1client "github.com/openshift/client-go/machine/clientset/versioned/typed/machine/v1beta1"
+)
+
+const (
+	machineAPINamespace = "openshift-machine-api"
+	machineSetLabel     = "machine.openshift.io/cluster-api-machineset"
+)
+
+// ErrMachineAPIUnavailable is returned by the MachineSet helpers on clusters without the Machine
+// API, so that callers can skip.
+var ErrMachineAPIUnavailable = errors.New("the Machine API is not available on this cluster")
+
+// ScaleMachineSet changes the replicas of the named MachineSet by delta and waits until its
+// Machines are Running and their nodes are Ready. The returned restore func scales the MachineSet
+// back to its original replicas and waits until the nodes added meanwhile are gone. It is
+// registered to run at teardown and may be called earlier, it only runs once.
+func ScaleMachineSet(oc *CLI, name string, delta int32, timeout time.Duration) (restore func(), err error) {
+	client, err := machineSetClient(oc)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := client.MachineSets(machineAPINamespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	original := int32(1)
+	if ms.Spec.Replicas != nil {
+		original = *ms.Spec.Replicas
+	}
+	replicas := original + delta
+	if replicas < 0 {
+		return nil, fmt.Errorf("cannot scale MachineSet %s with %d replicas by %d", name, original, delta)
+	}
+	existingNodes, err := machineSetNodes(client, name)
+	if err != nil {
+		return nil, err
+	}
+
+	e2e.Logf("Scaling MachineSet %s from %d to %d replicas", name, original, replicas)
+	if err := setMachineSetReplicas(client, name, replicas); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	restore = func() {
+		once.Do(func() {
+			if err := restoreMachineSet(oc, client, name, original, existingNodes, timeout); err != nil {
+				e2e.Logf("Unable to restore MachineSet %s: %v", name, err)
+			}
+		})
+	}
+	g.DeferCleanup(restore)
+
+	return restore, waitForMachineSet(oc, client, name, replicas, timeout)
+}
+
+// machineSetClient returns a machine API client, or ErrMachineAPIUnavailable when the MachineAPI
+// capability is disabled or the API is not served.
+func machineSetClient(oc *CLI) (machinev1beta1client.MachineV1beta1Interface, error) {
+	enabled, err := HasCapability(oc, configv1.ClusterVersionCapabilityMachineAPI)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrMachineAPIUnavailable
+	}
+	_, err = oc.AdminKubeClient().Discovery().ServerResourcesForGroupVersion(machinev1beta1.GroupVersion.String())
+	if kapierrs.IsNotFound(err) {
+		return nil, ErrMachineAPIUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return machineclient.NewForConfigOrDie(oc.AdminConfig()).MachineV1beta1(), nil
+}
+
+func setMachineSetReplicas(client machinev1beta1client.MachineV1beta1Interface, name string, replicas int32) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	_, err := client.MachineSets(machineAPINamespace).Patch(context.Background(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func listMachineSetMachines(ctx context.Context, client machinev1beta1client.MachineV1beta1Interface, name string) ([]machinev1beta1.Machine, error) {
+	machines, err := client.Machines(machineAPINamespace).List(ctx, metav1.ListOptions{LabelSelector: machineSetLabel + "=" + name})
+	if err != nil {
+		return nil, err
+	}
+	return machines.Items, nil
+}
+
+// machineSetNodes returns the names of the nodes of the Machines of the MachineSet.
+func machineSetNodes(client machinev1beta1client.MachineV1beta1Interface, name string) (sets.Set[string], error) {
+	machines, err := listMachineSetMachines(context.Background(), client, name)
+	if err != nil {
+		return nil, err
+	}
+	nodes := sets.New[string]()
+	for _, machine := range machines {
+		if machine.Status.NodeRef != nil {
+			nodes.Insert(machine.Status.NodeRef.Name)
+		}
+	}
+	return nodes, nil
+}
+
+// waitForMachineSet waits until the MachineSet has exactly replicas Machines, all Running with a
+// Ready node. On timeout the returned error describes the Machines that are not.
+func waitForMachineSet(oc *CLI, client machinev1beta1client.MachineV1beta1Interface, name string, replicas int32, timeout time.Duration) error {
+	var problems []string
+	err := pollUntilTimeout(context.Background(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		machines, err := listMachineSetMachines(ctx, client, name)
+		if err != nil {
+			e2e.Logf("Unable to list the Machines of MachineSet %s: %v", name, err)
+			return false, nil
+		}
+		problems = nil
+		if int32(len(machines)) != replicas {
+			problems = append(problems, fmt.Sprintf("%d machines instead of %d", len(machines), replicas))
+		}
+		for _, machine := range machines {
+			if problem := machineProblem(ctx, oc, &machine); len(problem) > 0 {
+				problems = append(problems, fmt.Sprintf("machine %s %s", machine.Name, problem))
+			}
+		}
+		return len(problems) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for MachineSet %s to have %d running machines: %w: %s", name, replicas, err, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// machineProblem returns why the machine is not Running with a Ready node, or an empty string.
+func machineProblem(ctx context.Context, oc *CLI, machine *machinev1beta1.Machine) string {
+	if machine.DeletionTimestamp != nil {
+		return "is being deleted"
+	}
+	if machine.Status.Phase == nil || *machine.Status.Phase != machinev1beta1.PhaseRunning {
+		phase := "unknown"
+		if machine.Status.Phase != nil {
+			phase = *machine.Status.Phase
+		}
+		return fmt.Sprintf("is in phase %s", phase)
+	}
+	if machine.Status.NodeRef == nil {
+		return "has no node"
+	}
+	node, err := oc.AdminKubeClient().CoreV1().Nodes().Get(ctx, machine.Status.NodeRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("has node %s that cannot be read: %v", machine.Status.NodeRef.Name, err)
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+			return ""
+		}
+	}
+	return fmt.Sprintf("has node %s that is not Ready", node.Name)
+}
+
+// restoreMachineSet scales the MachineSet back to replicas and waits until the nodes of its
+// Machines, other than the existing ones, are deleted.
+func restoreMachineSet(oc *CLI, client machinev1beta1client.MachineV1beta1Interface, name string, replicas int32, existingNodes sets.Set[string], timeout time.Duration) error {
+	addedNodes, err := machineSetNodes(client, name)
+	if err != nil {
+		return err
+	}
+	addedNodes = addedNodes.Difference(existingNodes)
+
+	e2e.Logf("Restoring MachineSet %s to %d replicas", name, replicas)
+	if err := setMachineSetReplicas(client, name, replicas); err != nil {
+		return err
+	}
+	err = pollUntilTimeout(context.Background(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		for _, node := range sets.List(addedNodes) {
+			_, err := oc.AdminKubeClient().CoreV1().Nodes().Get(ctx, node, metav1.GetOptions{})
+			switch {
+			case kapierrs.IsNotFound(err):
+				addedNodes.Delete(node)
+			case err != nil:
+				e2e.Logf("Unable to get node %s: %v", node, err)
+			}
+		}
+		return addedNodes.Len() == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for nodes %v to go away: %w", sets.List(addedNodes), err)
+	}
+	return waitForMachineSet(oc, client, name, replicas, timeout)
+}
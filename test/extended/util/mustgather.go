@@ -0,0 +1,126 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// mustGatherNamespaceRegexp matches the line `oc adm must-gather` prints when it creates the
+// temporary namespace it runs in.
+var mustGatherNamespaceRegexp = regexp.MustCompile(`namespace/(openshift-must-gather-[a-z0-9]+) created`)
+
+// MustGatherResult is the output of RunMustGather.
+type MustGatherResult struct {
+	// Dir is the directory holding the gathered data, below the destination directory when
+	// must-gather nested it in a directory named after the image.
+	Dir string
+}
+
+// FileExists returns true if the file at relpath, relative to Dir, exists.
+func (r MustGatherResult) FileExists(relpath string) bool {
+	path, err := r.path(relpath)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// ReadFile returns the content of the file at relpath, relative to Dir.
+func (r MustGatherResult) ReadFile(relpath string) ([]byte, error) {
+	path, err := r.path(relpath)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (r MustGatherResult) path(relpath string) (string, error) {
+	if !filepath.IsLocal(relpath) {
+		return "", fmt.Errorf("%q is not a path within the must-gather output", relpath)
+	}
+	return filepath.Join(r.Dir, relpath), nil
+}
+
+// RunMustGather runs `oc adm must-gather` with the image, or the default image when empty, and
+// returns where the data was gathered in destDir. When destDir is empty a directory from
+// oc.TempDir is used. The command is killed after timeout, and the namespace it created is
+// deleted when it is interrupted, since must-gather only deletes it when it exits by itself.
+func RunMustGather(oc *CLI, image string, destDir string, timeout time.Duration) (MustGatherResult, error) {
+	if len(destDir) == 0 {
+		dir, err := oc.TempDir()
+		if err != nil {
+			return MustGatherResult{}, err
+		}
+		destDir = dir
+	}
+
+	args := []string{"--dest-dir", destDir}
+	if len(image) > 0 {
+		args = append(args, "--image", image)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stdout, stderr, err := oc.AsAdmin().WithoutNamespace().Run("adm", "must-gather").Args(args...).OutputsStreaming(ctx, nil, nil)
+	if ctx.Err() != nil {
+		deleteMustGatherNamespace(oc, mustGatherNamespaceFromOutput(stdout+"\n"+stderr))
+		return MustGatherResult{}, fmt.Errorf("must-gather did not finish within %s: %w", timeout, err)
+	}
+	if err != nil {
+		return MustGatherResult{}, err
+	}
+
+	dir, err := resolveMustGatherDir(destDir)
+	if err != nil {
+		return MustGatherResult{}, err
+	}
+	e2e.Logf("must-gather output is in %s", dir)
+	return MustGatherResult{Dir: dir}, nil
+}
+
+// resolveMustGatherDir returns the only directory in destDir, in which must-gather puts the data
+// gathered by an image, or destDir itself when there is none or several of them.
+func resolveMustGatherDir(destDir string) (string, error) {
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		return "", err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	if len(dirs) != 1 {
+		return destDir, nil
+	}
+	return filepath.Join(destDir, dirs[0]), nil
+}
+
+// mustGatherNamespaceFromOutput returns the temporary namespace created by the must-gather that
+// printed output, or an empty string if it was not created yet.
+func mustGatherNamespaceFromOutput(output string) string {
+	match := mustGatherNamespaceRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// deleteMustGatherNamespace deletes the namespace created by an interrupted must-gather. Other
+// must-gather namespaces are left alone, since they may belong to must-gathers still running.
+func deleteMustGatherNamespace(oc *CLI, ns string) {
+	if len(ns) == 0 {
+		e2e.Logf("Unable to find the namespace created by must-gather in its output")
+		return
+	}
+	err := oc.AdminKubeClient().CoreV1().Namespaces().Delete(context.Background(), ns, metav1.DeleteOptions{})
+	e2e.Logf("Deleted must-gather namespace %s, err: %v", ns, err)
+}
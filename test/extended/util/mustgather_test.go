@@ -0,0 +1,63 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMustGatherResult(t *testing.T) {
+	destDir := t.TempDir()
+	imageDir := filepath.Join(destDir, "quay-io-openshift-must-gather-sha256-0123")
+	if err := os.MkdirAll(filepath.Join(imageDir, "cluster-scoped-resources"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(imageDir, "cluster-scoped-resources", "nodes.yaml"), []byte("kind: NodeList"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "timestamp"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := resolveMustGatherDir(destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != imageDir {
+		t.Fatalf("expected the image directory %s, got %s", imageDir, dir)
+	}
+
+	result := MustGatherResult{Dir: dir}
+	if !result.FileExists("cluster-scoped-resources/nodes.yaml") {
+		t.Errorf("expected nodes.yaml to exist")
+	}
+	if result.FileExists("cluster-scoped-resources/pods.yaml") {
+		t.Errorf("expected pods.yaml not to exist")
+	}
+	if content, err := result.ReadFile("cluster-scoped-resources/nodes.yaml"); err != nil || string(content) != "kind: NodeList" {
+		t.Errorf("unexpected content %q and error %v", content, err)
+	}
+	if _, err := result.ReadFile("../timestamp"); err == nil {
+		t.Errorf("expected an error reading outside of the output")
+	}
+
+	// several image directories are left as they are
+	if err := os.Mkdir(filepath.Join(destDir, "other-image"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := resolveMustGatherDir(destDir); err != nil || dir != destDir {
+		t.Errorf("expected %s, got %s and %v", destDir, dir, err)
+	}
+}
+
+func TestMustGatherNamespaceFromOutput(t *testing.T) {
+	output := `[must-gather      ] OUT Using must-gather plug-in image: quay.io/openshift/origin-must-gather:latest
+[must-gather      ] OUT namespace/openshift-must-gather-5hs8f created
+[must-gather      ] OUT clusterrolebinding.rbac.authorization.k8s.io/must-gather-w2x7b created`
+	if ns := mustGatherNamespaceFromOutput(output); ns != "openshift-must-gather-5hs8f" {
+		t.Errorf("expected namespace openshift-must-gather-5hs8f, got %q", ns)
+	}
+	if ns := mustGatherNamespaceFromOutput("[must-gather      ] OUT Using must-gather plug-in image"); ns != "" {
+		t.Errorf("expected no namespace before it is created, got %q", ns)
+	}
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	o "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+	utilnet "k8s.io/utils/net"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned"
+)
+
+// getNetworkConfig returns the cluster Network config object.
+func getNetworkConfig(configClient configv1client.Interface) (*configv1.Network, error) {
+	network, err := configClient.ConfigV1().Networks().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failure getting test cluster Network: %w", err)
+	}
+	return network, nil
+}
+
+// NetworkPluginName returns the network type of the cluster, e.g. "OVNKubernetes".
+func NetworkPluginName(oc *CLI) (string, error) {
+	network, err := getNetworkConfig(oc.AdminConfigClient())
+	if err != nil {
+		return "", err
+	}
+	return networkPluginNameFromConfig(network)
+}
+
+// IsDualStack returns true if the cluster network has both IPv4 and IPv6 entries.
+func IsDualStack(oc *CLI) (bool, error) {
+	network, err := getNetworkConfig(oc.AdminConfigClient())
+	if err != nil {
+		return false, err
+	}
+	return isDualStackFromConfig(network)
+}
+
+// PrimaryIPFamily returns the IP family of the first service network of the cluster.
+func PrimaryIPFamily(oc *CLI) (corev1.IPFamily, error) {
+	network, err := getNetworkConfig(oc.AdminConfigClient())
+	if err != nil {
+		return "", err
+	}
+	return primaryIPFamilyFromConfig(network)
+}
+
+// SkipUnlessNetworkPlugin skips the test unless the cluster uses one of the given network plugins.
+func SkipUnlessNetworkPlugin(oc *CLI, plugins ...string) {
+	pluginName, err := NetworkPluginName(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the network plugin")
+	for _, plugin := range plugins {
+		if plugin == pluginName {
+			return
+		}
+	}
+	skipper.Skipf("Network plugin %q is not one of %v", pluginName, plugins)
+}
+
+// networkPluginNameFromConfig prefers the status network type and falls back to the spec.
+func networkPluginNameFromConfig(network *configv1.Network) (string, error) {
+	switch {
+	case len(network.Status.NetworkType) > 0:
+		return network.Status.NetworkType, nil
+	case len(network.Spec.NetworkType) > 0:
+		return network.Spec.NetworkType, nil
+	default:
+		return "", fmt.Errorf("networks.%s/cluster: status|spec.networkType not found", configv1.GroupName)
+	}
+}
+
+// networkCIDRs returns the cluster and service network CIDRs, preferring the status over the spec.
+func networkCIDRs(network *configv1.Network) (clusterNetwork, serviceNetwork []string) {
+	clusterNetworkEntries := network.Status.ClusterNetwork
+	if len(clusterNetworkEntries) == 0 {
+		clusterNetworkEntries = network.Spec.ClusterNetwork
+	}
+	for _, entry := range clusterNetworkEntries {
+		clusterNetwork = append(clusterNetwork, entry.CIDR)
+	}
+	serviceNetwork = network.Status.ServiceNetwork
+	if len(serviceNetwork) == 0 {
+		serviceNetwork = network.Spec.ServiceNetwork
+	}
+	return clusterNetwork, serviceNetwork
+}
+
+func isDualStackFromConfig(network *configv1.Network) (bool, error) {
+	clusterNetwork, serviceNetwork := networkCIDRs(network)
+	if len(clusterNetwork) == 0 && len(serviceNetwork) == 0 {
+		return false, fmt.Errorf("networks.%s/cluster: status|spec.clusterNetwork and serviceNetwork not found", configv1.GroupName)
+	}
+	var hasIPv4, hasIPv6 bool
+	for _, cidr := range append(clusterNetwork, serviceNetwork...) {
+		switch {
+		case utilnet.IsIPv4CIDRString(cidr):
+			hasIPv4 = true
+		case utilnet.IsIPv6CIDRString(cidr):
+			hasIPv6 = true
+		default:
+			return false, fmt.Errorf("networks.%s/cluster: invalid CIDR %q", configv1.GroupName, cidr)
+		}
+	}
+	return hasIPv4 && hasIPv6, nil
+}
+
+func primaryIPFamilyFromConfig(network *configv1.Network) (corev1.IPFamily, error) {
+	clusterNetwork, serviceNetwork := networkCIDRs(network)
+	cidrs := append(append([]string{}, serviceNetwork...), clusterNetwork...)
+	if len(cidrs) == 0 {
+		return "", fmt.Errorf("networks.%s/cluster: status|spec.serviceNetwork not found", configv1.GroupName)
+	}
+	switch {
+	case utilnet.IsIPv4CIDRString(cidrs[0]):
+		return corev1.IPv4Protocol, nil
+	case utilnet.IsIPv6CIDRString(cidrs[0]):
+		return corev1.IPv6Protocol, nil
+	default:
+		return "", fmt.Errorf("networks.%s/cluster: invalid CIDR %q", configv1.GroupName, cidrs[0])
+	}
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	fakeconfigv1client "github.com/openshift/client-go/config/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func networkWithCIDRs(networkType string, clusterNetwork []string, serviceNetwork []string) *configv1.Network {
+	network := &configv1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.NetworkStatus{
+			NetworkType:    networkType,
+			ServiceNetwork: serviceNetwork,
+		},
+	}
+	for _, cidr := range clusterNetwork {
+		network.Status.ClusterNetwork = append(network.Status.ClusterNetwork, configv1.ClusterNetworkEntry{CIDR: cidr, HostPrefix: 23})
+	}
+	return network
+}
+
+func TestNetworkStackDetection(t *testing.T) {
+	tests := []struct {
+		name            string
+		network         *configv1.Network
+		expectedPlugin  string
+		expectedDual    bool
+		expectedFamily  corev1.IPFamily
+		expectedFailure bool
+	}{
+		{
+			name:           "single stack ipv4",
+			network:        networkWithCIDRs("OVNKubernetes", []string{"10.128.0.0/14"}, []string{"172.30.0.0/16"}),
+			expectedPlugin: "OVNKubernetes",
+			expectedFamily: corev1.IPv4Protocol,
+		},
+		{
+			name:           "single stack ipv6",
+			network:        networkWithCIDRs("OVNKubernetes", []string{"fd01::/48"}, []string{"fd02::/112"}),
+			expectedPlugin: "OVNKubernetes",
+			expectedFamily: corev1.IPv6Protocol,
+		},
+		{
+			name:           "dual stack ipv4 primary",
+			network:        networkWithCIDRs("OVNKubernetes", []string{"10.128.0.0/14", "fd01::/48"}, []string{"172.30.0.0/16", "fd02::/112"}),
+			expectedPlugin: "OVNKubernetes",
+			expectedDual:   true,
+			expectedFamily: corev1.IPv4Protocol,
+		},
+		{
+			name:           "dual stack ipv6 primary",
+			network:        networkWithCIDRs("Calico", []string{"fd01::/48", "10.128.0.0/14"}, []string{"fd02::/112", "172.30.0.0/16"}),
+			expectedPlugin: "Calico",
+			expectedDual:   true,
+			expectedFamily: corev1.IPv6Protocol,
+		},
+		{
+			name: "status not populated falls back to spec",
+			network: &configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: configv1.NetworkSpec{
+					NetworkType:    "OpenShiftSDN",
+					ClusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}},
+					ServiceNetwork: []string{"172.30.0.0/16"},
+				},
+			},
+			expectedPlugin: "OpenShiftSDN",
+			expectedFamily: corev1.IPv4Protocol,
+		},
+		{
+			name:            "empty network",
+			network:         &configv1.Network{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+			expectedFailure: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			network, err := getNetworkConfig(fakeconfigv1client.NewSimpleClientset(test.network))
+			if err != nil {
+				t.Fatalf("unexpected error getting the network config: %v", err)
+			}
+
+			plugin, pluginErr := networkPluginNameFromConfig(network)
+			dualStack, dualStackErr := isDualStackFromConfig(network)
+			family, familyErr := primaryIPFamilyFromConfig(network)
+			if test.expectedFailure {
+				if pluginErr == nil || dualStackErr == nil || familyErr == nil {
+					t.Fatalf("expected errors, got plugin=%v, dualStack=%v, family=%v", pluginErr, dualStackErr, familyErr)
+				}
+				return
+			}
+			if pluginErr != nil || dualStackErr != nil || familyErr != nil {
+				t.Fatalf("unexpected errors: plugin=%v, dualStack=%v, family=%v", pluginErr, dualStackErr, familyErr)
+			}
+			if plugin != test.expectedPlugin {
+				t.Errorf("expected plugin %q, got %q", test.expectedPlugin, plugin)
+			}
+			if dualStack != test.expectedDual {
+				t.Errorf("expected dual stack %v, got %v", test.expectedDual, dualStack)
+			}
+			if family != test.expectedFamily {
+				t.Errorf("expected family %q, got %q", test.expectedFamily, family)
+			}
+		})
+	}
+}
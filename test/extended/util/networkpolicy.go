@@ -0,0 +1,44 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// CreateNetworkPolicy creates the NetworkPolicy, in the current namespace unless the policy sets
+// one. The policy is deleted when the test ends.
+func (c *CLI) CreateNetworkPolicy(policy *networkingv1.NetworkPolicy) error {
+	ns := policy.Namespace
+	if len(ns) == 0 {
+		ns = c.Namespace()
+	}
+	created, err := c.AdminKubeClient().NetworkingV1().NetworkPolicies(ns).Create(context.Background(), policy, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create networkpolicy %s/%s: %w", ns, policy.Name, err)
+	}
+	c.AddResourceToDelete(networkingv1.SchemeGroupVersion.WithResource("networkpolicies"), created)
+	return nil
+}
+
+// ProbeConnectivity opens a TCP connection with nc from fromPod, in the current namespace, to the
+// port of toPodIP and reports whether it was allowed. A connection that is refused or times out
+// is reported as not allowed; other failures, such as nc missing from the pod, are errors. Exec
+// pods created with CreateExecPod have nc.
+func (c *CLI) ProbeConnectivity(fromPod, toPodIP string, port int) (bool, error) {
+	_, stderr, err := ExecInPod(context.Background(), c, c.Namespace(), fromPod, "", "nc", "-z", "-w", "5", toPodIP, strconv.Itoa(port))
+	if err == nil {
+		return true, nil
+	}
+	var execErr *ExecError
+	if errors.As(err, &execErr) && execErr.ExitCode == 1 {
+		e2e.Logf("Connection from %s to %s:%d was not allowed: %s", fromPod, toPodIP, port, stderr)
+		return false, nil
+	}
+	return false, fmt.Errorf("unable to probe %s:%d from pod %s: %w", toPodIP, port, fromPod, err)
+}
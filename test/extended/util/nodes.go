@@ -1,10 +1,17 @@
 package util
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
 	"strings"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	admissionapi "k8s.io/pod-security-admission/api"
 )
 
 // GetClusterNodesByRole returns the cluster nodes by role
@@ -13,6 +20,24 @@ func GetClusterNodesByRole(oc *CLI, role string) ([]string, error) {
 	return strings.Split(strings.Trim(nodes, "'"), " "), err
 }
 
+// NodesWithRole returns the nodes with the role. The "master" and "control-plane" roles are
+// synonyms and match nodes carrying either label, so that compact clusters, where nodes also hold
+// the worker role, and clusters labeling control-plane nodes only with
+// node-role.kubernetes.io/control-plane are both handled. An error is returned if no node has
+// the role.
+func (c *CLI) NodesWithRole(role string) ([]corev1.Node, error) {
+	return nodesWithRole(c.AdminKubeClient(), role)
+}
+
+// FirstNodeWithRole returns the first node with the role, as defined by NodesWithRole.
+func (c *CLI) FirstNodeWithRole(role string) (*corev1.Node, error) {
+	nodes, err := c.NodesWithRole(role)
+	if err != nil {
+		return nil, err
+	}
+	return &nodes[0], nil
+}
+
 // GetFirstCoreOsWorkerNode returns the first CoreOS worker node
 func GetFirstCoreOsWorkerNode(oc *CLI) (string, error) {
 	return getFirstNodeByOsID(oc, "worker", "rhcos")
@@ -40,7 +65,7 @@ func DebugNodeRetryWithOptionsAndChroot(oc *CLI, nodeName string, debugNodeNames
 	)
 	cargs = []string{"node/" + nodeName, "-n" + debugNodeNamespace, "--", "chroot", "/host"}
 	cargs = append(cargs, cmd...)
-	wait.Poll(3*time.Second, 30*time.Second, func() (bool, error) {
+	poll(3*time.Second, 30*time.Second, false, func() (bool, error) {
 		stdOut, _, err = oc.AsAdmin().WithoutNamespace().Run("debug").Args(cargs...).Outputs()
 		if err != nil {
 			return false, nil
@@ -49,3 +74,157 @@ func DebugNodeRetryWithOptionsAndChroot(oc *CLI, nodeName string, debugNodeNames
 	})
 	return stdOut, err
 }
+
+// transientDebugFailures are the stderr messages of `oc debug node` failures caused by the debug
+// pod failing to start, which are worth retrying.
+var transientDebugFailures = []string{
+	"unable to create the debug pod",
+	"timed out waiting for the condition",
+	"unable to upgrade connection",
+	"container not found",
+}
+
+// DebugNode runs cmd chrooted into the host filesystem of the node with `oc debug node`. The debug
+// pod runs in a temporary namespace that enforces the privileged pod security level, which is
+// deleted when done. The command is retried once when the debug pod fails to start.
+func DebugNode(oc *CLI, nodeName string, cmd ...string) (stdout, stderr string, err error) {
+	ns, err := oc.AdminKubeClient().CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "e2e-debug-node-",
+			Labels: map[string]string{
+				admissionapi.EnforceLevelLabel:                   string(admissionapi.LevelPrivileged),
+				admissionapi.AuditLevelLabel:                     string(admissionapi.LevelPrivileged),
+				admissionapi.WarnLevelLabel:                      string(admissionapi.LevelPrivileged),
+				"security.openshift.io/scc.podSecurityLabelSync": "false",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create a namespace to debug node %s: %w", nodeName, err)
+	}
+	defer func() {
+		if err := oc.AdminKubeClient().CoreV1().Namespaces().Delete(context.Background(), ns.Name, metav1.DeleteOptions{}); err != nil {
+			e2e.Logf("Unable to delete namespace %s: %v", ns.Name, err)
+		}
+	}()
+
+	args := append([]string{"node/" + nodeName, "--to-namespace=" + ns.Name, "--", "chroot", "/host"}, cmd...)
+	for attempt := 1; ; attempt++ {
+		stdout, stderr, err = oc.AsAdmin().WithoutNamespace().Run("debug").Args(args...).Outputs()
+		if err == nil || attempt > 1 || !isTransientDebugFailure(stderr) {
+			return stdout, stderr, err
+		}
+		e2e.Logf("Debug pod on node %s failed to start, retrying: %s", nodeName, stderr)
+	}
+}
+
+// DebugNodeRetryingAllNodes runs cmd with DebugNode on the nodes with the role, one at a time,
+// until it succeeds on one of them, and returns the name of that node and the output. This suits
+// checks that need any one healthy node.
+func (c *CLI) DebugNodeRetryingAllNodes(role string, cmd ...string) (nodeName, stdout string, err error) {
+	nodes, err := c.NodesWithRole(role)
+	if err != nil {
+		return "", "", err
+	}
+	var errs []string
+	for _, node := range nodes {
+		stdout, stderr, err := DebugNode(c, node.Name, cmd...)
+		if err == nil {
+			return node.Name, stdout, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v: %s", node.Name, err, stderr))
+	}
+	return "", "", fmt.Errorf("command failed on every node with role %q:\n%s", role, strings.Join(errs, "\n"))
+}
+
+func isTransientDebugFailure(stderr string) bool {
+	for _, message := range transientDebugFailures {
+		if strings.Contains(stderr, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNodesByRole returns the nodes with the role, as defined by CLI.NodesWithRole.
+func GetNodesByRole(oc *CLI, role string) ([]corev1.Node, error) {
+	return oc.NodesWithRole(role)
+}
+
+// GetSchedulableWorkers returns the worker nodes that can run test workloads: nodes that are
+// schedulable, Ready and not tainted to repel pods. An error is returned if there is none.
+func GetSchedulableWorkers(oc *CLI) ([]corev1.Node, error) {
+	return getSchedulableWorkers(oc.AdminKubeClient())
+}
+
+// RandomNode returns one of the nodes with the role, as defined by CLI.NodesWithRole.
+func RandomNode(oc *CLI, role string) (*corev1.Node, error) {
+	nodes, err := oc.NodesWithRole(role)
+	if err != nil {
+		return nil, err
+	}
+	return &nodes[rand.Intn(len(nodes))], nil
+}
+
+func nodesWithRole(kubeClient kubernetes.Interface, role string) ([]corev1.Node, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var withRole []corev1.Node
+	for _, node := range nodes.Items {
+		if nodeHasRole(&node, role) {
+			withRole = append(withRole, node)
+		}
+	}
+	if len(withRole) == 0 {
+		return nil, fmt.Errorf("no nodes found with role %q", role)
+	}
+	return withRole, nil
+}
+
+func getSchedulableWorkers(kubeClient kubernetes.Interface) ([]corev1.Node, error) {
+	workers, err := nodesWithRole(kubeClient, "worker")
+	if err != nil {
+		return nil, err
+	}
+	var schedulable []corev1.Node
+	for _, node := range workers {
+		if isSchedulableNode(&node) {
+			schedulable = append(schedulable, node)
+		}
+	}
+	if len(schedulable) == 0 {
+		return nil, fmt.Errorf("none of the %d worker nodes is schedulable", len(workers))
+	}
+	return schedulable, nil
+}
+
+func nodeHasRole(node *corev1.Node, role string) bool {
+	switch role {
+	case "master", "control-plane":
+		_, master := node.Labels[masterNodeRoleLabel]
+		_, controlPlane := node.Labels[controlPlaneNodeRoleLabel]
+		return master || controlPlane
+	default:
+		_, ok := node.Labels["node-role.kubernetes.io/"+role]
+		return ok
+	}
+}
+
+func isSchedulableNode(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+			return false
+		}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
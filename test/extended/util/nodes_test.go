@@ -0,0 +1,153 @@
+package util
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testNode returns a Ready node with the roles, for the tests listing nodes with a fake client.
+func testNode(name string, roles ...string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	for _, role := range roles {
+		node.Labels["node-role.kubernetes.io/"+role] = ""
+	}
+	return node
+}
+
+// notReady marks node NotReady.
+func notReady(node *corev1.Node) *corev1.Node {
+	node.Status.Conditions[0].Status = corev1.ConditionFalse
+	return node
+}
+
+// withArch sets the architecture label of node.
+func withArch(node *corev1.Node, arch string) *corev1.Node {
+	node.Labels[corev1.LabelArchStable] = arch
+	return node
+}
+
+func nodeNames(nodes []corev1.Node) []string {
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestNodesWithRole(t *testing.T) {
+	tainted := testNode("worker-tainted", "worker")
+	tainted.Spec.Taints = []corev1.Taint{{Key: "e2e-test", Effect: corev1.TaintEffectNoSchedule}}
+	cordoned := testNode("worker-cordoned", "worker")
+	cordoned.Spec.Unschedulable = true
+	preferNoSchedule := testNode("worker-prefer", "worker")
+	preferNoSchedule.Spec.Taints = []corev1.Taint{{Key: "e2e-test", Effect: corev1.TaintEffectPreferNoSchedule}}
+
+	tests := []struct {
+		name                string
+		nodes               []runtime.Object
+		role                string
+		expected            []string
+		expectedErr         bool
+		expectedSchedulable []string
+	}{
+		{
+			name: "master and control-plane labels",
+			nodes: []runtime.Object{
+				testNode("master-0", "master"),
+				testNode("master-1", "control-plane"),
+				testNode("master-2", "master", "control-plane"),
+				testNode("worker-0", "worker"),
+			},
+			role:                "master",
+			expected:            []string{"master-0", "master-1", "master-2"},
+			expectedSchedulable: []string{"worker-0"},
+		},
+		{
+			name: "control-plane is a synonym of master",
+			nodes: []runtime.Object{
+				testNode("master-0", "master"),
+				testNode("master-1", "control-plane"),
+				testNode("worker-0", "worker"),
+			},
+			role:                "control-plane",
+			expected:            []string{"master-0", "master-1"},
+			expectedSchedulable: []string{"worker-0"},
+		},
+		{
+			name: "compact cluster workers",
+			nodes: []runtime.Object{
+				testNode("master-0", "master", "worker"),
+				testNode("master-1", "control-plane", "worker"),
+				notReady(testNode("master-2", "master", "worker")),
+			},
+			role:                "worker",
+			expected:            []string{"master-0", "master-1", "master-2"},
+			expectedSchedulable: []string{"master-0", "master-1"},
+		},
+		{
+			name: "unschedulable workers are filtered",
+			nodes: []runtime.Object{
+				testNode("master-0", "master"),
+				testNode("worker-0", "worker"),
+				notReady(testNode("worker-notready", "worker")),
+				tainted,
+				cordoned,
+				preferNoSchedule,
+			},
+			role:                "worker",
+			expected:            []string{"worker-0", "worker-cordoned", "worker-notready", "worker-prefer", "worker-tainted"},
+			expectedSchedulable: []string{"worker-0", "worker-prefer"},
+		},
+		{
+			name: "no node with the role",
+			nodes: []runtime.Object{
+				testNode("master-0", "master"),
+				testNode("worker-0", "worker"),
+			},
+			role:                "infra",
+			expectedErr:         true,
+			expectedSchedulable: []string{"worker-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.nodes...)
+
+			nodes, err := nodesWithRole(client, tt.role)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := nodeNames(nodes); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected nodes %v, got %v", tt.expected, got)
+			}
+
+			schedulable, err := getSchedulableWorkers(client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := nodeNames(schedulable); !reflect.DeepEqual(got, tt.expectedSchedulable) {
+				t.Errorf("expected schedulable workers %v, got %v", tt.expectedSchedulable, got)
+			}
+		})
+	}
+}
+
+func TestGetSchedulableWorkersNone(t *testing.T) {
+	client := fake.NewSimpleClientset(notReady(testNode("worker-0", "worker")))
+
+	if _, err := getSchedulableWorkers(client); err == nil {
+		t.Errorf("expected an error without schedulable workers")
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+// oauthMetadata holds the subset of the OAuth 2.0 authorization server metadata
+// (RFC 8414) served by the API server that login-flow tests need.
+type oauthMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// OAuthWellKnown returns the issuer, authorization and token endpoints published by the
+// API server at /.well-known/oauth-authorization-server.
+func (c *CLI) OAuthWellKnown() (*oauthMetadata, error) {
+	config := c.AdminConfig()
+	transport, err := restclient.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(strings.TrimSuffix(config.Host, "/") + "/.well-known/oauth-authorization-server")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the OAuth metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the OAuth metadata: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d getting the OAuth metadata: %s", resp.StatusCode, string(body))
+	}
+
+	metadata := &oauthMetadata{}
+	if err := json.Unmarshal(body, metadata); err != nil {
+		return nil, fmt.Errorf("unable to decode the OAuth metadata: %w", err)
+	}
+	return metadata, nil
+}
@@ -9,7 +9,6 @@ import (
 	userv1 "github.com/openshift/api/user/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/third_party/forked/golang/netutil"
 	restclient "k8s.io/client-go/rest"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -53,7 +52,7 @@ func getUserPartOfNickname(clientCfg *restclient.Config) (string, error) {
 	}
 
 	var userInfo *userv1.User
-	err = wait.PollUntilContextTimeout(context.Background(), time.Second, time.Minute, true, func(ctx context.Context) (done bool, err error) {
+	err = pollUntilTimeout(context.Background(), time.Second, time.Minute, true, func(ctx context.Context) (done bool, err error) {
 		userInfo, err = userClient.Users().Get(ctx, "~", metav1.GetOptions{})
 		if err != nil && strings.Contains(err.Error(), "connect: connection refused") {
 			return false, nil
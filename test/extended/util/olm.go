@@ -0,0 +1,101 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+var (
+	clusterServiceVersionsGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+	subscriptionsGVR          = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+	installPlansGVR           = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans"}
+	catalogSourcesGVR         = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "catalogsources"}
+	operatorGroupsGVR         = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1", Resource: "operatorgroups"}
+)
+
+// OLMClient gives access to the operators.coreos.com resources. The OLM clientset is not
+// vendored, so the resources are served as unstructured objects by a dynamic client.
+type OLMClient struct {
+	client dynamic.Interface
+}
+
+func (c *OLMClient) ClusterServiceVersions(namespace string) dynamic.ResourceInterface {
+	return c.client.Resource(clusterServiceVersionsGVR).Namespace(namespace)
+}
+
+func (c *OLMClient) Subscriptions(namespace string) dynamic.ResourceInterface {
+	return c.client.Resource(subscriptionsGVR).Namespace(namespace)
+}
+
+func (c *OLMClient) InstallPlans(namespace string) dynamic.ResourceInterface {
+	return c.client.Resource(installPlansGVR).Namespace(namespace)
+}
+
+func (c *OLMClient) CatalogSources(namespace string) dynamic.ResourceInterface {
+	return c.client.Resource(catalogSourcesGVR).Namespace(namespace)
+}
+
+func (c *OLMClient) OperatorGroups(namespace string) dynamic.ResourceInterface {
+	return c.client.Resource(operatorGroupsGVR).Namespace(namespace)
+}
+
+// AdminOLMClient provides an OLM client for the cluster admin user.
+func (c *CLI) AdminOLMClient() *OLMClient {
+	return &OLMClient{client: c.AdminDynamicClient()}
+}
+
+// WaitForCSVSucceeded waits for the ClusterServiceVersion installed by a Subscription, whose name
+// starts with csvPrefix, to reach the Succeeded phase, and returns its name. CSV names carry the
+// operator version, so the prefix is usually the package name. The CSV is the one recorded in
+// status.installedCSV of the Subscription, so that the CSV being replaced during an upgrade is
+// not picked.
+func (c *CLI) WaitForCSVSucceeded(namespace, csvPrefix string, timeout time.Duration) (string, error) {
+	var name, phase, message string
+	err := pollUntilTimeout(context.Background(), 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		subscriptions, err := c.AdminOLMClient().Subscriptions(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			e2e.Logf("Unable to list Subscriptions in %s: %v", namespace, err)
+			return false, nil
+		}
+		name = installedCSVWithPrefix(subscriptions.Items, csvPrefix)
+		if len(name) == 0 {
+			return false, nil
+		}
+		csv, err := c.AdminOLMClient().ClusterServiceVersions(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			e2e.Logf("Unable to get ClusterServiceVersion %s/%s: %v", namespace, name, err)
+			return false, nil
+		}
+		phase, _, _ = unstructured.NestedString(csv.Object, "status", "phase")
+		message, _, _ = unstructured.NestedString(csv.Object, "status", "message")
+		return phase == "Succeeded", nil
+	})
+	if err != nil {
+		if len(name) == 0 {
+			return "", fmt.Errorf("no Subscription in %s installed a ClusterServiceVersion with prefix %q: %w", namespace, csvPrefix, err)
+		}
+		return "", fmt.Errorf("ClusterServiceVersion %s/%s did not succeed: %w (phase %q: %s)", namespace, name, err, phase, message)
+	}
+	e2e.Logf("ClusterServiceVersion %s/%s succeeded", namespace, name)
+	return name, nil
+}
+
+// installedCSVWithPrefix returns the first installed CSV of the subscriptions whose name starts
+// with prefix, or an empty string if there is none.
+func installedCSVWithPrefix(subscriptions []unstructured.Unstructured, prefix string) string {
+	for _, subscription := range subscriptions {
+		installed, _, _ := unstructured.NestedString(subscription.Object, "status", "installedCSV")
+		if len(installed) > 0 && strings.HasPrefix(installed, prefix) {
+			return installed
+		}
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func subscriptionWithStatus(currentCSV, installedCSV string) unstructured.Unstructured {
+	status := map[string]interface{}{"currentCSV": currentCSV}
+	if len(installedCSV) > 0 {
+		status["installedCSV"] = installedCSV
+	}
+	return unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+}
+
+func TestInstalledCSVWithPrefix(t *testing.T) {
+	subscriptions := []unstructured.Unstructured{
+		subscriptionWithStatus("other-operator.v1.0.0", "other-operator.v1.0.0"),
+		subscriptionWithStatus("etcd-operator.v0.9.4", "etcd-operator.v0.9.2"),
+	}
+	if got := installedCSVWithPrefix(subscriptions, "etcd-operator"); got != "etcd-operator.v0.9.2" {
+		t.Errorf("expected the installed CSV etcd-operator.v0.9.2, got %q", got)
+	}
+
+	pending := []unstructured.Unstructured{subscriptionWithStatus("etcd-operator.v0.9.4", "")}
+	if got := installedCSVWithPrefix(pending, "etcd-operator"); got != "" {
+		t.Errorf("expected no CSV before one is installed, got %q", got)
+	}
+}
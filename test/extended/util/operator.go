@@ -3,12 +3,16 @@ package util
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
 	configv1client "github.com/openshift/client-go/config/clientset/versioned"
 	"github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 	watchtools "k8s.io/client-go/tools/watch"
@@ -158,3 +162,73 @@ func WaitForOperatorToRollout(ctx context.Context, configClient configv1client.I
 
 	return ret
 }
+
+// WaitForClusterOperatorReady waits for the ClusterOperator to be Available=True, Progressing=False
+// and Degraded=False. On timeout the returned error lists the conditions that are off.
+func WaitForClusterOperatorReady(oc *CLI, name string, timeout time.Duration) error {
+	var problems []string
+	err := pollUntilTimeout(context.Background(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		co, err := oc.AdminConfigClient().ConfigV1().ClusterOperators().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			problems = []string{err.Error()}
+			return false, nil
+		}
+		problems = clusterOperatorProblems(co)
+		return len(problems) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("clusteroperator/%s is not ready: %w\n\t%s", name, err, strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// WaitForAllClusterOperatorsReady waits for every ClusterOperator except the tolerated ones, e.g.
+// insights on disconnected clusters, to be Available=True, Progressing=False and Degraded=False.
+// On timeout the returned error lists each operator that is not ready and its conditions.
+func WaitForAllClusterOperatorsReady(oc *CLI, timeout time.Duration, tolerated ...string) error {
+	toleratedSet := sets.New[string](tolerated...)
+	var problems []string
+	err := pollUntilTimeout(context.Background(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		operators, err := oc.AdminConfigClient().ConfigV1().ClusterOperators().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			problems = []string{err.Error()}
+			return false, nil
+		}
+		problems = nil
+		for i := range operators.Items {
+			if toleratedSet.Has(operators.Items[i].Name) {
+				continue
+			}
+			problems = append(problems, clusterOperatorProblems(&operators.Items[i])...)
+		}
+		return len(problems) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("clusteroperators are not ready: %w\n\t%s", err, strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// clusterOperatorProblems describes the conditions of the ClusterOperator that are not
+// Available=True, Progressing=False and Degraded=False.
+func clusterOperatorProblems(co *configv1.ClusterOperator) []string {
+	var problems []string
+	for _, expected := range []struct {
+		conditionType configv1.ClusterStatusConditionType
+		status        configv1.ConditionStatus
+	}{
+		{configv1.OperatorAvailable, configv1.ConditionTrue},
+		{configv1.OperatorProgressing, configv1.ConditionFalse},
+		{configv1.OperatorDegraded, configv1.ConditionFalse},
+	} {
+		condition := v1helpers.FindStatusCondition(co.Status.Conditions, expected.conditionType)
+		switch {
+		case condition == nil:
+			problems = append(problems, fmt.Sprintf("clusteroperator/%s has no %s condition", co.Name, expected.conditionType))
+		case condition.Status != expected.status:
+			problems = append(problems, fmt.Sprintf("clusteroperator/%s is %s=%s since %s: %s: %s", co.Name, expected.conditionType, condition.Status,
+				condition.LastTransitionTime.Format(time.RFC3339), condition.Reason, condition.Message))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// WaitForOrderedPhases watches the named object of the resource, which may be cluster scoped when
+// namespace is empty, until the field at phasePath (a JSONPath such as "{.status.phase}" or
+// ".status.phase") reaches the last of phases. The object must move through phases in the given
+// order, one step at a time: skipping a phase, going back to an earlier one or reporting a phase
+// that is not listed is an error. The first observed phase may be any of phases, since the object
+// may have progressed before the watch started. Errors report the sequence of observed phases.
+func (c *CLI) WaitForOrderedPhases(gvr schema.GroupVersionResource, namespace, name string, phases []string, phasePath string, timeout time.Duration) error {
+	if len(phases) == 0 {
+		return fmt.Errorf("no phases to wait for")
+	}
+	parser, err := phaseParser(phasePath)
+	if err != nil {
+		return err
+	}
+	sequence := &phaseSequence{expected: phases, current: -1}
+	_, err = WaitForResourceCondition(c, gvr, namespace, name, func(obj *unstructured.Unstructured) (bool, error) {
+		phase, err := readPhase(parser, obj)
+		if err != nil {
+			return false, err
+		}
+		return sequence.observe(phase)
+	}, timeout)
+	if err != nil {
+		return fmt.Errorf("%s %s/%s did not go through phases %s: %w (observed %s)", gvr.Resource, namespace, name, strings.Join(phases, "→"), err, sequence)
+	}
+	return nil
+}
+
+// phaseParser parses path, accepting it with or without the surrounding braces.
+func phaseParser(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	parser := jsonpath.New("phase").AllowMissingKeys(true)
+	if err := parser.Parse(path); err != nil {
+		return nil, fmt.Errorf("invalid phase path %q: %w", path, err)
+	}
+	return parser, nil
+}
+
+// readPhase returns the phase of obj, which is empty when the field is not set.
+func readPhase(parser *jsonpath.JSONPath, obj *unstructured.Unstructured) (string, error) {
+	var buf bytes.Buffer
+	if err := parser.Execute(&buf, obj.Object); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// phaseSequence checks that observed phases follow expected one step at a time.
+type phaseSequence struct {
+	expected []string
+	// current is the index in expected of the last observed phase, -1 before the first one
+	current  int
+	observed []string
+}
+
+// observe records phase and returns true once the last expected phase is reached. An empty phase
+// is ignored until the first phase has been observed.
+func (s *phaseSequence) observe(phase string) (bool, error) {
+	if len(s.observed) > 0 && s.observed[len(s.observed)-1] == phase {
+		return s.current == len(s.expected)-1, nil
+	}
+	if phase == "" && s.current == -1 {
+		return false, nil
+	}
+	s.observed = append(s.observed, phase)
+
+	next := -1
+	for i, expected := range s.expected {
+		if expected == phase {
+			next = i
+			break
+		}
+	}
+	switch {
+	case next == -1:
+		return false, fmt.Errorf("unexpected phase %q", phase)
+	case s.current == -1:
+	case next < s.current:
+		return false, fmt.Errorf("went back from phase %q to %q", s.expected[s.current], phase)
+	case next > s.current+1:
+		return false, fmt.Errorf("skipped phase %q", s.expected[s.current+1])
+	}
+	s.current = next
+	return s.current == len(s.expected)-1, nil
+}
+
+func (s *phaseSequence) String() string {
+	if len(s.observed) == 0 {
+		return "no phase"
+	}
+	return strings.Join(s.observed, "→")
+}
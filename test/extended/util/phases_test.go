@@ -0,0 +1,104 @@
+package util
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPhaseSequence(t *testing.T) {
+	expected := []string{"Pending", "Running", "Succeeded"}
+	tests := []struct {
+		name     string
+		observed []string
+		wantDone bool
+		wantErr  bool
+		want     string
+	}{
+		{
+			name:     "all phases in order",
+			observed: []string{"", "Pending", "Pending", "Running", "Succeeded"},
+			wantDone: true,
+			want:     "Pending→Running→Succeeded",
+		},
+		{
+			name:     "started after the first phase",
+			observed: []string{"Running", "Succeeded"},
+			wantDone: true,
+			want:     "Running→Succeeded",
+		},
+		{
+			name:     "still in progress",
+			observed: []string{"Pending", "Running"},
+			want:     "Pending→Running",
+		},
+		{
+			name:     "skipped phase",
+			observed: []string{"Pending", "Succeeded"},
+			wantErr:  true,
+			want:     "Pending→Succeeded",
+		},
+		{
+			name:     "went backward",
+			observed: []string{"Pending", "Running", "Pending"},
+			wantErr:  true,
+			want:     "Pending→Running→Pending",
+		},
+		{
+			name:     "unexpected phase",
+			observed: []string{"Pending", "Failed"},
+			wantErr:  true,
+			want:     "Pending→Failed",
+		},
+		{
+			name:     "phase cleared",
+			observed: []string{"Pending", ""},
+			wantErr:  true,
+			want:     "Pending→",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sequence := &phaseSequence{expected: expected, current: -1}
+			var done bool
+			var err error
+			for _, phase := range tt.observed {
+				if done, err = sequence.observe(phase); err != nil {
+					break
+				}
+			}
+			if done != tt.wantDone {
+				t.Errorf("got done %t, want %t", done, tt.wantDone)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got := sequence.String(); got != tt.want {
+				t.Errorf("got sequence %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPhase(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Running"},
+	}}
+	for _, path := range []string{".status.phase", "{.status.phase}"} {
+		parser, err := phaseParser(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if phase, err := readPhase(parser, obj); err != nil || phase != "Running" {
+			t.Errorf("%s: got %q, %v", path, phase, err)
+		}
+	}
+
+	parser, err := phaseParser(".status.missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phase, err := readPhase(parser, obj); err != nil || phase != "" {
+		t.Errorf("missing field: got %q, %v", phase, err)
+	}
+}
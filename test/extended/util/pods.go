@@ -2,6 +2,8 @@ package util
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,12 +13,12 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	kutilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	clientset "k8s.io/client-go/kubernetes"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 	podframework "k8s.io/kubernetes/test/e2e/framework/pod"
 	e2eskipper "k8s.io/kubernetes/test/e2e/framework/skipper"
+	admissionapi "k8s.io/pod-security-admission/api"
 
 	"github.com/openshift/origin/test/extended/util/image"
 )
@@ -30,7 +32,7 @@ const (
 // (The idling tests use a DeploymentConfig which will leave a "Completed" deploy pod
 // after deploying the service; we don't want to count that.)
 func WaitForNoPodsRunning(oc *CLI) error {
-	return wait.Poll(200*time.Millisecond, 3*time.Minute, func() (bool, error) {
+	return poll(200*time.Millisecond, 3*time.Minute, false, func() (bool, error) {
 		pods, err := oc.KubeClient().CoreV1().Pods(oc.Namespace()).List(context.Background(), metav1.ListOptions{})
 		if err != nil {
 			return false, err
@@ -124,3 +126,127 @@ func ExecCommandOnMachineConfigDaemon(c clientset.Interface, oc *CLI, node *core
 	args := append(initialArgs, command...)
 	return oc.AsAdmin().Run("rsh").Args(args...).Output()
 }
+
+// SnapshotRestartCounts returns the restart count of every container of the pods in the
+// namespace, keyed by <pod>/<container>.
+func (c *CLI) SnapshotRestartCounts(namespace string) (map[string]int32, error) {
+	pods, err := c.AdminKubeClient().CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int32{}
+	for _, pod := range pods.Items {
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			counts[pod.Name+"/"+status.Name] = status.RestartCount
+		}
+	}
+	return counts, nil
+}
+
+// AssertNoNewRestarts returns an error naming each container of the namespace that
+// restarted since the snapshot was taken, along with its number of restarts. Containers
+// of pods created after the snapshot are compared against zero restarts.
+func (c *CLI) AssertNoNewRestarts(namespace string, before map[string]int32) error {
+	after, err := c.SnapshotRestartCounts(namespace)
+	if err != nil {
+		return err
+	}
+	var restarted []string
+	for container, count := range after {
+		if delta := count - before[container]; delta > 0 {
+			restarted = append(restarted, fmt.Sprintf("%s restarted %d times", container, delta))
+		}
+	}
+	if len(restarted) > 0 {
+		sort.Strings(restarted)
+		return fmt.Errorf("containers in namespace %s restarted: %s", namespace, strings.Join(restarted, ", "))
+	}
+	return nil
+}
+
+// badWaitingReasons are the container waiting reasons PodsInBadState reports.
+var badWaitingReasons = map[string]bool{
+	"CrashLoopBackOff": true,
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+}
+
+// PodsInBadState returns the containers of the pods in the namespace that are waiting because
+// they crash loop or their image cannot be pulled, as <pod>/<container>: <reason>: <message>.
+// Tests can call it to fail or skip early instead of timing out later.
+func (c *CLI) PodsInBadState(namespace string) ([]string, error) {
+	pods, err := c.AdminKubeClient().CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var bad []string
+	for _, pod := range pods.Items {
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if waiting := status.State.Waiting; waiting != nil && badWaitingReasons[waiting.Reason] {
+				bad = append(bad, fmt.Sprintf("%s/%s: %s: %s", pod.Name, status.Name, waiting.Reason, waiting.Message))
+			}
+		}
+	}
+	sort.Strings(bad)
+	return bad, nil
+}
+
+// CreateExecPod creates a long running pod with the shell image to exec commands in, e.g. with
+// ExecInPod, and waits for it to run. The pod complies with the restricted pod security profile
+// so that it can run in any namespace. It is deleted when the test ends.
+func CreateExecPod(oc *CLI, ns, name string) (*corev1.Pod, error) {
+	return createExecPod(oc, ns, name, func(pod *corev1.Pod) {
+		pod.Spec.SecurityContext = podframework.GetRestrictedPodSecurityContext()
+		pod.Spec.Containers[0].SecurityContext = podframework.GetRestrictedContainerSecurityContext()
+	})
+}
+
+// CreateHostNetworkExecPod creates a privileged exec pod like CreateExecPod that runs in the host
+// network namespace. The namespace must enforce the privileged pod security level, e.g. by creating
+// the CLI with NewCLIWithPodSecurityLevel(project, admissionapi.LevelPrivileged).
+func CreateHostNetworkExecPod(oc *CLI, ns, name string) (*corev1.Pod, error) {
+	namespace, err := oc.AdminKubeClient().CoreV1().Namespaces().Get(context.Background(), ns, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if level := namespace.Labels[admissionapi.EnforceLevelLabel]; level != string(admissionapi.LevelPrivileged) {
+		return nil, fmt.Errorf("namespace %s enforces the %q pod security level, host network exec pods require %q", ns, level, admissionapi.LevelPrivileged)
+	}
+	return createExecPod(oc, ns, name, func(pod *corev1.Pod) {
+		privileged := true
+		pod.Spec.HostNetwork = true
+		pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+	})
+}
+
+func createExecPod(oc *CLI, ns, name string, tweak func(*corev1.Pod)) (*corev1.Pod, error) {
+	immediate := int64(0)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: corev1.PodSpec{
+			TerminationGracePeriodSeconds: &immediate,
+			Containers: []corev1.Container{
+				{
+					Name:    "exec",
+					Image:   image.ShellImage(),
+					Command: []string{"sh", "-c", "trap exit TERM; while true; do sleep 5; done"},
+				},
+			},
+		},
+	}
+	tweak(pod)
+
+	pod, err := oc.AdminKubeClient().CoreV1().Pods(ns).Create(context.Background(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create exec pod %s/%s: %w", ns, name, err)
+	}
+	oc.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("pods"), pod)
+
+	if err := oc.WaitForPodRunning(ns, name, 5*time.Minute); err != nil {
+		return nil, err
+	}
+	return oc.AdminKubeClient().CoreV1().Pods(ns).Get(context.Background(), name, metav1.GetOptions{})
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// WaitForPodCondition waits for the condition to be true for the pod. The pod being
+// deleted is an error. On timeout the returned error describes the last observed pod.
+func (c *CLI) WaitForPodCondition(ns, name string, cond func(*corev1.Pod) (bool, error), timeout time.Duration) error {
+	return c.waitForPodEvent(ns, name, timeout, nil, func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			return cond(event.Object.(*corev1.Pod))
+		case watch.Deleted:
+			return false, fmt.Errorf("pod %s/%s was deleted", ns, name)
+		default:
+			return false, nil
+		}
+	})
+}
+
+// WaitForPodRunning waits for the pod to be running. A pod that completed is an error.
+func (c *CLI) WaitForPodRunning(ns, name string, timeout time.Duration) error {
+	return c.WaitForPodCondition(ns, name, func(pod *corev1.Pod) (bool, error) {
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("pod %s/%s completed with phase %s", ns, name, pod.Status.Phase)
+		}
+		return false, nil
+	}, timeout)
+}
+
+// WaitForPodSucceeded waits for the pod to succeed. A pod that failed is an error.
+func (c *CLI) WaitForPodSucceeded(ns, name string, timeout time.Duration) error {
+	return c.WaitForPodCondition(ns, name, func(pod *corev1.Pod) (bool, error) {
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			return true, nil
+		case corev1.PodFailed:
+			return false, fmt.Errorf("pod %s/%s failed: %s", ns, name, describePod(pod))
+		}
+		return false, nil
+	}, timeout)
+}
+
+// WaitForPodDeleted waits for the pod to be gone.
+func (c *CLI) WaitForPodDeleted(ns, name string, timeout time.Duration) error {
+	precondition := func(store cache.Store) (bool, error) {
+		_, exists, err := store.GetByKey(ns + "/" + name)
+		return !exists, err
+	}
+	return c.waitForPodEvent(ns, name, timeout, precondition, func(event watch.Event) (bool, error) {
+		return event.Type == watch.Deleted, nil
+	})
+}
+
+func (c *CLI) waitForPodEvent(ns, name string, timeout time.Duration, precondition watchtools.PreconditionFunc, condition watchtools.ConditionFunc) error {
+	client := c.AdminKubeClient().CoreV1().Pods(ns)
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return client.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return client.Watch(ctx, options)
+		},
+	}
+
+	var lastObserved *corev1.Pod
+	_, err := watchtools.UntilWithSync(ctx, lw, &corev1.Pod{}, precondition, func(event watch.Event) (bool, error) {
+		if pod, ok := event.Object.(*corev1.Pod); ok {
+			lastObserved = pod
+		}
+		return condition(event)
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == nil {
+		return err
+	}
+	if lastObserved == nil {
+		return fmt.Errorf("timed out waiting for pod %s/%s: %w (pod not observed)", ns, name, err)
+	}
+	return fmt.Errorf("timed out waiting for pod %s/%s: %w (last observed: %s)", ns, name, err, describePod(lastObserved))
+}
+
+// describePod summarizes the phase, conditions and container statuses of the pod for error messages.
+func describePod(pod *corev1.Pod) string {
+	var conditions []string
+	for _, condition := range pod.Status.Conditions {
+		conditions = append(conditions, fmt.Sprintf("%s=%s", condition.Type, condition.Status))
+	}
+	var containers []string
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		state := "unknown"
+		switch {
+		case status.State.Waiting != nil:
+			state = fmt.Sprintf("waiting (%s: %s)", status.State.Waiting.Reason, status.State.Waiting.Message)
+		case status.State.Running != nil:
+			state = "running"
+		case status.State.Terminated != nil:
+			state = fmt.Sprintf("terminated (%s, exit code %d: %s)", status.State.Terminated.Reason, status.State.Terminated.ExitCode, status.State.Terminated.Message)
+		}
+		containers = append(containers, fmt.Sprintf("%s %s ready=%v restarts=%d", status.Name, state, status.Ready, status.RestartCount))
+	}
+	return fmt.Sprintf("phase=%s conditions=[%s] containers=[%s]", pod.Status.Phase, strings.Join(conditions, ", "), strings.Join(containers, "; "))
+}
@@ -0,0 +1,143 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// ErrPortForwardLost is returned when the port forward to a pod ends unexpectedly, which
+// happens when the pod is deleted or its container restarts. Callers can establish a new
+// port forward once the pod is running again.
+var ErrPortForwardLost = errors.New("port forward to pod lost")
+
+var (
+	// lostPortForwards holds the errors of the port forwards that ended without being
+	// stopped, by local address.
+	lostPortForwards     = map[string]error{}
+	lostPortForwardsLock sync.Mutex
+)
+
+// portForwarder is the part of portforward.PortForwarder used by PortForwardToPod.
+type portForwarder interface {
+	ForwardPorts() error
+	GetPorts() ([]portforward.ForwardedPort, error)
+}
+
+// PortForwardToPod forwards a free local port to the remote port of the pod and returns
+// the local address once the listener is ready. The returned stop function closes the port
+// forward, it is also called when the current spec ends. If the port forward ends before it
+// is ready, the error wraps ErrPortForwardLost. If it ends later without being stopped,
+// PortForwardLost reports it for the local address.
+func (c *CLI) PortForwardToPod(ns, pod string, remotePort int) (localAddr string, stop func(), err error) {
+	config := c.UserConfig()
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return "", nil, err
+	}
+	u := c.KubeClient().CoreV1().RESTClient().Post().Resource("pods").Namespace(ns).Name(pod).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", u)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(stopCh) })
+	}
+
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", remotePort)}, stopCh, readyCh, io.Discard, g.GinkgoWriter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	target := fmt.Sprintf("%s/%s:%d", ns, pod, remotePort)
+	done := c.backgroundWork.trackGoroutine("port forward to pod "+target, stop)
+	// the local address is only known once ready, it is handed to lost when the forward ends
+	addrCh := make(chan string, 1)
+	lost := func(err error) {
+		if addr, ok := <-addrCh; ok {
+			setPortForwardLost(addr, err)
+		}
+	}
+	localPort, err := startPortForward(forwarder, readyCh, stopCh, done, target, lost)
+	if err != nil {
+		close(addrCh)
+		stop()
+		return "", nil, err
+	}
+	localAddr = net.JoinHostPort("127.0.0.1", strconv.Itoa(int(localPort)))
+	setPortForwardLost(localAddr, nil)
+	addrCh <- localAddr
+	g.DeferCleanup(stop)
+
+	e2e.Logf("Forwarding %s to pod %s", localAddr, target)
+	return localAddr, stop, nil
+}
+
+// PortForwardLost returns an error wrapping ErrPortForwardLost if the port forward started by
+// PortForwardToPod on localAddr ended without being stopped, and nil otherwise.
+func PortForwardLost(localAddr string) error {
+	lostPortForwardsLock.Lock()
+	defer lostPortForwardsLock.Unlock()
+	return lostPortForwards[localAddr]
+}
+
+// setPortForwardLost records err for localAddr, a nil err clears it when the address is reused.
+func setPortForwardLost(localAddr string, err error) {
+	lostPortForwardsLock.Lock()
+	defer lostPortForwardsLock.Unlock()
+	if err == nil {
+		delete(lostPortForwards, localAddr)
+		return
+	}
+	lostPortForwards[localAddr] = err
+}
+
+// startPortForward runs forwarder in the background until stopCh is closed and returns the
+// local port once readyCh is closed. done is called when the forwarder returns, and lost with
+// an error wrapping ErrPortForwardLost if it returns after being ready without being stopped.
+func startPortForward(forwarder portForwarder, readyCh, stopCh <-chan struct{}, done func(), target string, lost func(error)) (localPort uint16, err error) {
+	errCh := make(chan error, 1)
+	go func() {
+		defer g.GinkgoRecover()
+		defer done()
+		err := forwarder.ForwardPorts()
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		e2e.Logf("Port forward to pod %s ended: %v", target, err)
+		select {
+		case <-readyCh:
+			lost(fmt.Errorf("%w: %s: %v", ErrPortForwardLost, target, err))
+		default:
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return 0, fmt.Errorf("%w: %s: %v", ErrPortForwardLost, target, err)
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		return 0, err
+	}
+	if len(ports) == 0 {
+		return 0, fmt.Errorf("no local port is forwarded to pod %s", target)
+	}
+	return ports[0].Local, nil
+}
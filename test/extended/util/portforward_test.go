@@ -0,0 +1,122 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// fakePortForwarder becomes ready immediately unless failBeforeReady is set, and forwards until
+// stopCh or failCh is closed.
+type fakePortForwarder struct {
+	readyCh         chan struct{}
+	stopCh          chan struct{}
+	failCh          chan struct{}
+	failBeforeReady bool
+	noPorts         bool
+}
+
+func newFakePortForwarder() *fakePortForwarder {
+	return &fakePortForwarder{readyCh: make(chan struct{}), stopCh: make(chan struct{}), failCh: make(chan struct{})}
+}
+
+func (f *fakePortForwarder) ForwardPorts() error {
+	if f.failBeforeReady {
+		return errors.New("pod not running")
+	}
+	close(f.readyCh)
+	select {
+	case <-f.stopCh:
+		return nil
+	case <-f.failCh:
+		return errors.New("lost connection to pod")
+	}
+}
+
+func (f *fakePortForwarder) GetPorts() ([]portforward.ForwardedPort, error) {
+	if f.noPorts {
+		return nil, nil
+	}
+	return []portforward.ForwardedPort{{Local: 40123, Remote: 8080}}, nil
+}
+
+func TestStartPortForwardLostAfterReady(t *testing.T) {
+	f := newFakePortForwarder()
+	lost := make(chan error, 1)
+	localPort, err := startPortForward(f, f.readyCh, f.stopCh, func() {}, "ns/pod:8080", func(err error) { lost <- err })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if localPort != 40123 {
+		t.Errorf("expected local port 40123, got %d", localPort)
+	}
+
+	close(f.failCh)
+	select {
+	case err := <-lost:
+		if !errors.Is(err, ErrPortForwardLost) {
+			t.Errorf("expected ErrPortForwardLost, got %v", err)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the lost port forward was not reported")
+	}
+}
+
+func TestStartPortForwardStopped(t *testing.T) {
+	f := newFakePortForwarder()
+	lost := make(chan error, 1)
+	ended := make(chan struct{})
+	if _, err := startPortForward(f, f.readyCh, f.stopCh, func() { close(ended) }, "ns/pod:8080", func(err error) { lost <- err }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	close(f.stopCh)
+	select {
+	case <-ended:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the port forward did not end once stopped")
+	}
+	select {
+	case err := <-lost:
+		t.Errorf("expected no error once stopped, got %v", err)
+	default:
+	}
+}
+
+func TestStartPortForwardFailsBeforeReady(t *testing.T) {
+	f := newFakePortForwarder()
+	f.failBeforeReady = true
+	if _, err := startPortForward(f, f.readyCh, f.stopCh, func() {}, "ns/pod:8080", func(error) {}); !errors.Is(err, ErrPortForwardLost) {
+		t.Errorf("expected ErrPortForwardLost, got %v", err)
+	}
+}
+
+func TestStartPortForwardNoPorts(t *testing.T) {
+	f := newFakePortForwarder()
+	f.noPorts = true
+	defer close(f.stopCh)
+	if _, err := startPortForward(f, f.readyCh, f.stopCh, func() {}, "ns/pod:8080", func(error) {}); err == nil {
+		t.Errorf("expected an error when no port is forwarded")
+	}
+}
+
+func TestPortForwardLost(t *testing.T) {
+	const addr = "127.0.0.1:40123"
+	defer setPortForwardLost(addr, nil)
+
+	if err := PortForwardLost(addr); err != nil {
+		t.Fatalf("expected no error for a running port forward, got %v", err)
+	}
+	setPortForwardLost(addr, fmt.Errorf("%w: ns/pod:8080", ErrPortForwardLost))
+	if err := PortForwardLost(addr); !errors.Is(err, ErrPortForwardLost) {
+		t.Errorf("expected ErrPortForwardLost, got %v", err)
+	}
+	setPortForwardLost(addr, nil)
+	if err := PortForwardLost(addr); err != nil {
+		t.Errorf("expected the error to be cleared when the address is reused, got %v", err)
+	}
+}
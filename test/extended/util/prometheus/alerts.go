@@ -0,0 +1,171 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubernetes/test/e2e/framework"
+
+	exutil "github.com/openshift/origin/test/extended/util"
+)
+
+// WaitForAlertFiring waits until an instance of the alert whose labels include labels is firing.
+// Pending instances do not count, the returned error on timeout lists them with the time they
+// became active so that an alert whose "for" duration is longer than timeout is easy to spot.
+func WaitForAlertFiring(oc *exutil.CLI, alertName string, labels map[string]string, timeout time.Duration) error {
+	ctx := context.Background()
+	client := oc.NewPrometheusClient(ctx)
+
+	var pending []prometheusv1.Alert
+	err := wait.PollUntilContextTimeout(ctx, 15*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		alerts, err := alertInstances(ctx, client, alertName, labels)
+		if err != nil {
+			framework.Logf("Unable to get the active alerts: %v", err)
+			return false, nil
+		}
+		pending = nil
+		for _, alert := range alerts {
+			switch alert.State {
+			case prometheusv1.AlertStateFiring:
+				framework.Logf("Alert is firing: %s", describeAlert(alert))
+				return true, nil
+			case prometheusv1.AlertStatePending:
+				pending = append(pending, alert)
+			}
+		}
+		return false, nil
+	})
+	if err == nil {
+		return nil
+	}
+	if len(pending) == 0 {
+		return fmt.Errorf("alert %s%s did not fire: %w (not active)", alertName, formatLabels(labels), err)
+	}
+	return fmt.Errorf("alert %s%s did not fire: %w, still pending:\n%s", alertName, formatLabels(labels), err, describeAlerts(pending))
+}
+
+// AssertAlertNotFiringDuring runs fn and returns an error if an instance of the alert was firing
+// before, after or at any time while fn ran. Instances that fired and resolved while fn ran are
+// found through the ALERTS series, the others are reported with their full label set and the time
+// they became active. Pending instances are not violations and are only logged.
+func AssertAlertNotFiringDuring(oc *exutil.CLI, alertName string, fn func()) error {
+	ctx := context.Background()
+	client := oc.NewPrometheusClient(ctx)
+
+	before, err := alertInstances(ctx, client, alertName, nil)
+	if err != nil {
+		return fmt.Errorf("unable to get the active alerts: %w", err)
+	}
+	start := time.Now()
+	fn()
+	window := time.Since(start)
+	after, err := alertInstances(ctx, client, alertName, nil)
+	if err != nil {
+		return fmt.Errorf("unable to get the active alerts: %w", err)
+	}
+
+	violations := map[string]string{}
+	for _, alert := range append(before, after...) {
+		switch alert.State {
+		case prometheusv1.AlertStateFiring:
+			violations[alertKey(alert.Labels)] = describeAlert(alert)
+		case prometheusv1.AlertStatePending:
+			framework.Logf("Alert is pending: %s", describeAlert(alert))
+		}
+	}
+
+	// round the window up to whole seconds so that the range covers all of fn
+	rangeSeconds := max(1, int(math.Ceil(window.Seconds())))
+	query := fmt.Sprintf(`max_over_time(ALERTS{alertname=%q,alertstate="firing"}[%ds])`, alertName, rangeSeconds)
+	result, err := RunQuery(ctx, client, query)
+	if err != nil {
+		return fmt.Errorf("unable to query the alert history: %w", err)
+	}
+	for _, sample := range result.Data.Result {
+		key := alertKey(model.LabelSet(sample.Metric))
+		if _, ok := violations[key]; !ok {
+			violations[key] = fmt.Sprintf("%s state=firing (resolved, activeAt unknown)", key)
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	var descriptions []string
+	for _, description := range violations {
+		descriptions = append(descriptions, description)
+	}
+	sort.Strings(descriptions)
+	return fmt.Errorf("alert %s fired during the last %s:\n%s", alertName, window.Round(time.Second), strings.Join(descriptions, "\n"))
+}
+
+// alertInstances returns the active instances of the alert, pending or firing, whose labels
+// include labels.
+func alertInstances(ctx context.Context, client prometheusv1.API, alertName string, labels map[string]string) ([]prometheusv1.Alert, error) {
+	result, err := client.Alerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var instances []prometheusv1.Alert
+	for _, alert := range result.Alerts {
+		if alertMatches(alert.Labels, alertName, labels) {
+			instances = append(instances, alert)
+		}
+	}
+	return instances, nil
+}
+
+func alertMatches(alertLabels model.LabelSet, alertName string, labels map[string]string) bool {
+	if alertLabels[model.AlertNameLabel] != model.LabelValue(alertName) {
+		return false
+	}
+	for k, v := range labels {
+		if alertLabels[model.LabelName(k)] != model.LabelValue(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// alertKey identifies an alert instance by its labels, ignoring those that only exist on the
+// ALERTS series.
+func alertKey(labels model.LabelSet) string {
+	labels = labels.Clone()
+	delete(labels, model.MetricNameLabel)
+	delete(labels, "alertstate")
+	return labels.String()
+}
+
+func describeAlert(alert prometheusv1.Alert) string {
+	return fmt.Sprintf("%s state=%s activeAt=%s", alertKey(alert.Labels), alert.State, alert.ActiveAt.UTC().Format(time.RFC3339))
+}
+
+func describeAlerts(alerts []prometheusv1.Alert) string {
+	var descriptions []string
+	for _, alert := range alerts {
+		descriptions = append(descriptions, describeAlert(alert))
+	}
+	return strings.Join(descriptions, "\n")
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return toLabelSet(labels).String()
+}
+
+func toLabelSet(labels map[string]string) model.LabelSet {
+	set := model.LabelSet{}
+	for k, v := range labels {
+		set[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return set
+}
@@ -91,3 +91,21 @@ func TestMetricConditions_MatchesInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestAlertMatches(t *testing.T) {
+	labels := model.LabelSet{"alertname": "KubePodNotReady", "namespace": "e2e-test", "severity": "warning"}
+
+	assert.True(t, alertMatches(labels, "KubePodNotReady", nil))
+	assert.True(t, alertMatches(labels, "KubePodNotReady", map[string]string{"namespace": "e2e-test"}))
+	assert.False(t, alertMatches(labels, "KubePodNotReady", map[string]string{"namespace": "other"}))
+	assert.False(t, alertMatches(labels, "KubePodCrashLooping", nil))
+}
+
+func TestAlertKey(t *testing.T) {
+	fromAPI := model.LabelSet{"alertname": "Watchdog", "severity": "none"}
+	fromSeries := model.LabelSet{"__name__": "ALERTS", "alertname": "Watchdog", "alertstate": "firing", "severity": "none"}
+
+	assert.Equal(t, alertKey(fromAPI), alertKey(fromSeries))
+	assert.Equal(t, `{alertname="Watchdog", severity="none"}`, alertKey(fromSeries))
+	assert.Contains(t, fromSeries, model.LabelName("alertstate"), "alertKey must not modify the labels")
+}
@@ -0,0 +1,146 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"k8s.io/kubernetes/test/e2e/framework"
+
+	exutil "github.com/openshift/origin/test/extended/util"
+)
+
+// maxRangePoints is the largest number of points per series Prometheus returns for a range query.
+const maxRangePoints = 11000
+
+// Matrix is the result of a range query, one series per label set.
+type Matrix []Series
+
+// Series is the samples of one label set.
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// Sample is the value of a series at a point in time.
+type Sample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// MatrixPoint is a sample along with the labels of its series.
+type MatrixPoint struct {
+	Labels map[string]string
+	Sample
+}
+
+func (p MatrixPoint) String() string {
+	return fmt.Sprintf("%v=%g at %s", p.Labels, p.Value, p.Timestamp.UTC().Format(time.RFC3339))
+}
+
+// PrometheusQueryRange evaluates the query over [start, end] through the cluster's thanos querier.
+// When step would yield more points than Prometheus allows, it is increased to stay under the
+// limit.
+func PrometheusQueryRange(oc *exutil.CLI, query string, start, end time.Time, step time.Duration) (Matrix, error) {
+	ctx := context.Background()
+	client := oc.NewPrometheusClient(ctx)
+
+	queryRange := prometheusv1.Range{Start: start, End: end, Step: rangeStep(start, end, step)}
+	if queryRange.Step != step {
+		framework.Logf("Increased the step of query %q from %s to %s to stay under %d points", query, step, queryRange.Step, maxRangePoints)
+	}
+	var result model.Value
+	var warnings prometheusv1.Warnings
+	var err error
+	for i := 0; i < maxPrometheusQueryAttempts; i++ {
+		if i > 0 {
+			time.Sleep(prometheusQueryRetrySleep)
+		}
+		result, warnings, err = client.QueryRange(ctx, query, queryRange)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("range query %q failed: %w", query, err)
+	}
+	if len(warnings) > 0 {
+		framework.Logf("Range query %q returned warnings: %v", query, warnings)
+	}
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("range query %q returned a %s instead of a matrix", query, result.Type())
+	}
+	return matrixFromModel(matrix), nil
+}
+
+// MaxOverRange returns the sample with the largest value of the matrix, or false if it has no
+// samples. NaN values are ignored.
+func MaxOverRange(m Matrix) (MatrixPoint, bool) {
+	return extremeOverRange(m, func(value, current float64) bool { return value > current })
+}
+
+// MinOverRange returns the sample with the smallest value of the matrix, or false if it has no
+// samples. NaN values are ignored.
+func MinOverRange(m Matrix) (MatrixPoint, bool) {
+	return extremeOverRange(m, func(value, current float64) bool { return value < current })
+}
+
+// ExpectQueryBelowOverRange returns an error if the query reaches threshold at any point of
+// [start, end], reporting the worst sample with its series and timestamp.
+func ExpectQueryBelowOverRange(oc *exutil.CLI, query string, start, end time.Time, step time.Duration, threshold float64) error {
+	matrix, err := PrometheusQueryRange(oc, query, start, end, step)
+	if err != nil {
+		return err
+	}
+	worst, ok := MaxOverRange(matrix)
+	if ok && worst.Value >= threshold {
+		return fmt.Errorf("query %q reached %g between %s and %s, the threshold is %g: %s", query, worst.Value, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), threshold, worst)
+	}
+	return nil
+}
+
+func extremeOverRange(m Matrix, better func(value, current float64) bool) (MatrixPoint, bool) {
+	var extreme MatrixPoint
+	found := false
+	for _, series := range m {
+		for _, sample := range series.Samples {
+			if math.IsNaN(sample.Value) {
+				continue
+			}
+			if !found || better(sample.Value, extreme.Value) {
+				extreme = MatrixPoint{Labels: series.Labels, Sample: sample}
+				found = true
+			}
+		}
+	}
+	return extreme, found
+}
+
+// rangeStep returns step, increased if needed so that [start, end] has at most maxRangePoints
+// points. An increased step is rounded up to a whole second.
+func rangeStep(start, end time.Time, step time.Duration) time.Duration {
+	minStep := end.Sub(start) / (maxRangePoints - 1)
+	if step >= minStep && step > 0 {
+		return step
+	}
+	return max(minStep.Truncate(time.Second)+time.Second, time.Second)
+}
+
+func matrixFromModel(matrix model.Matrix) Matrix {
+	result := make(Matrix, 0, len(matrix))
+	for _, stream := range matrix {
+		series := Series{Labels: map[string]string{}}
+		for k, v := range stream.Metric {
+			series.Labels[string(k)] = string(v)
+		}
+		for _, pair := range stream.Values {
+			series.Samples = append(series.Samples, Sample{Timestamp: pair.Timestamp.Time(), Value: float64(pair.Value)})
+		}
+		result = append(result, series)
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package prometheus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeStep(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, rangeStep(start, start.Add(time.Hour), 30*time.Second))
+	assert.Equal(t, time.Second, rangeStep(start, start.Add(time.Hour), 0))
+
+	// a day at one second resolution is 86401 points
+	step := rangeStep(start, start.Add(24*time.Hour), time.Second)
+	assert.Equal(t, 8*time.Second, step)
+	assert.LessOrEqual(t, int(24*time.Hour/step)+1, maxRangePoints)
+}
+
+func TestOverRange(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	matrix := matrixFromModel(model.Matrix{
+		{
+			Metric: model.Metric{"instance": "a"},
+			Values: []model.SamplePair{
+				{Timestamp: model.TimeFromUnixNano(start.UnixNano()), Value: 1},
+				{Timestamp: model.TimeFromUnixNano(start.Add(time.Minute).UnixNano()), Value: model.SampleValue(math.NaN())},
+				{Timestamp: model.TimeFromUnixNano(start.Add(2 * time.Minute).UnixNano()), Value: 7},
+			},
+		},
+		{
+			Metric: model.Metric{"instance": "b"},
+			Values: []model.SamplePair{
+				{Timestamp: model.TimeFromUnixNano(start.Add(time.Minute).UnixNano()), Value: -2},
+			},
+		},
+	})
+
+	worst, ok := MaxOverRange(matrix)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"instance": "a"}, worst.Labels)
+	assert.Equal(t, 7.0, worst.Value)
+	assert.True(t, start.Add(2*time.Minute).Equal(worst.Timestamp))
+
+	lowest, ok := MinOverRange(matrix)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"instance": "b"}, lowest.Labels)
+	assert.Equal(t, -2.0, lowest.Value)
+
+	_, ok = MaxOverRange(Matrix{{Labels: map[string]string{}}})
+	assert.False(t, ok)
+}
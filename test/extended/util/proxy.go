@@ -2,9 +2,21 @@ package util
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	o "github.com/onsi/gomega"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
 )
 
 // IsClusterProxyEnabled returns true if the cluster has a global proxy enabled
@@ -18,3 +30,114 @@ func IsClusterProxyEnabled(oc *CLI) (bool, error) {
 	}
 	return len(proxy.Status.HTTPProxy) > 0 || len(proxy.Status.HTTPSProxy) > 0, nil
 }
+
+// GetClusterProxy returns the cluster-wide Proxy config object. An empty Proxy is
+// returned if the object does not exist.
+func GetClusterProxy(oc *CLI) (*configv1.Proxy, error) {
+	proxy, err := oc.AdminConfigClient().ConfigV1().Proxies().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return &configv1.Proxy{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failure getting test cluster Proxy: %w", err)
+	}
+	return proxy, nil
+}
+
+// ClusterProxy returns the HTTP proxy, HTTPS proxy and noProxy list observed in the
+// status of the cluster-wide Proxy config, e.g. to set HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY for tooling run by a test. Empty strings are returned when no proxy is configured.
+func (c *CLI) ClusterProxy() (httpProxy, httpsProxy, noProxy string, err error) {
+	proxy, err := GetClusterProxy(c)
+	if err != nil {
+		return "", "", "", err
+	}
+	return proxy.Status.HTTPProxy, proxy.Status.HTTPSProxy, proxy.Status.NoProxy, nil
+}
+
+// SkipIfProxied skips the test if the cluster has a global proxy enabled.
+func (c *CLI) SkipIfProxied(reason string) {
+	proxy, err := GetClusterProxy(c)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine the cluster proxy configuration")
+	if len(proxy.Status.HTTPProxy) > 0 || len(proxy.Status.HTTPSProxy) > 0 {
+		skipper.Skipf("Skipping on a proxied cluster: %s", reason)
+	}
+}
+
+// HTTPClientRespectingClusterProxy returns an HTTP client that sends requests
+// through the cluster-wide proxy, honoring its noProxy list, and that trusts the
+// proxy's trusted CA bundle in addition to the system roots.
+func HTTPClientRespectingClusterProxy(oc *CLI) (*http.Client, error) {
+	proxy, err := GetClusterProxy(oc)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if len(proxy.Spec.TrustedCA.Name) > 0 {
+		cm, err := oc.AdminKubeClient().CoreV1().ConfigMaps("openshift-config").Get(context.Background(), proxy.Spec.TrustedCA.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the proxy trusted CA configmap openshift-config/%s: %w", proxy.Spec.TrustedCA.Name, err)
+		}
+		caBundle, ok := cm.Data["ca-bundle.crt"]
+		if !ok {
+			return nil, fmt.Errorf("no ca-bundle.crt found in openshift-config/%s", proxy.Spec.TrustedCA.Name)
+		}
+		if !rootCAs.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, fmt.Errorf("no certificates found in openshift-config/%s", proxy.Spec.TrustedCA.Name)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	transport.Proxy = clusterProxyFunc(proxy.Status)
+	return &http.Client{Transport: transport}, nil
+}
+
+// clusterProxyFunc returns a transport proxy func for the observed proxy
+// configuration. As with the cluster network operator, noProxy entries may be
+// "*", IPs, CIDRs, or domains, where a domain also matches its subdomains.
+func clusterProxyFunc(status configv1.ProxyStatus) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := status.HTTPProxy
+		if req.URL.Scheme == "https" {
+			proxy = status.HTTPSProxy
+		}
+		if len(proxy) == 0 || noProxyMatches(status.NoProxy, req.URL.Hostname()) {
+			return nil, nil
+		}
+		return url.Parse(proxy)
+	}
+}
+
+// noProxyMatches returns true if the host matches an entry of the comma separated noProxy list.
+func noProxyMatches(noProxy, host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case len(entry) == 0:
+			continue
+		case entry == "*":
+			return true
+		}
+		if ip != nil {
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/util/retry"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	podframework "k8s.io/kubernetes/test/e2e/framework/pod"
+)
+
+// LinkPullSecretToSA adds the secret to the image pull secrets of the service account, like
+// `oc secrets link --for=pull`. Linking an already linked secret does nothing. A reference added
+// here is removed when the test ends.
+func LinkPullSecretToSA(oc *CLI, ns, secretName, saName string) error {
+	client := oc.AdminKubeClient().CoreV1().ServiceAccounts(ns)
+	added := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		sa, err := client.Get(context.Background(), saName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for _, ref := range sa.ImagePullSecrets {
+			if ref.Name == secretName {
+				return nil
+			}
+		}
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: secretName})
+		_, err = client.Update(context.Background(), sa, metav1.UpdateOptions{})
+		added = err == nil
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to link pull secret %s to service account %s/%s: %w", secretName, ns, saName, err)
+	}
+	if added {
+		g.DeferCleanup(func() {
+			if err := unlinkPullSecretFromSA(oc, ns, secretName, saName); err != nil {
+				e2e.Logf("Unable to unlink pull secret %s from service account %s/%s: %v", secretName, ns, saName, err)
+			}
+		})
+	}
+	return nil
+}
+
+func unlinkPullSecretFromSA(oc *CLI, ns, secretName, saName string) error {
+	client := oc.AdminKubeClient().CoreV1().ServiceAccounts(ns)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		sa, err := client.Get(context.Background(), saName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		var refs []corev1.LocalObjectReference
+		for _, ref := range sa.ImagePullSecrets {
+			if ref.Name != secretName {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) == len(sa.ImagePullSecrets) {
+			return nil
+		}
+		sa.ImagePullSecrets = refs
+		_, err = client.Update(context.Background(), sa, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// WaitForSAPullSecretUsable waits until a pod running as the service account can pull
+// privateImage, an image that requires the credentials linked to the service account. It runs
+// short-lived canary pods that are deleted once done, so that the test does not depend on how
+// long the pull secret takes to reach the kubelet.
+func WaitForSAPullSecretUsable(oc *CLI, ns, sa, privateImage string, timeout time.Duration) error {
+	podClient := oc.AdminKubeClient().CoreV1().Pods(ns)
+	immediate := int64(0)
+	var lastState string
+	err := pollUntilTimeout(context.Background(), 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := podClient.Create(ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pull-secret-canary-" + rand.String(5)},
+			Spec: corev1.PodSpec{
+				ServiceAccountName:            sa,
+				RestartPolicy:                 corev1.RestartPolicyNever,
+				TerminationGracePeriodSeconds: &immediate,
+				SecurityContext:               podframework.GetRestrictedPodSecurityContext(),
+				Containers: []corev1.Container{{
+					Name:            "canary",
+					Image:           privateImage,
+					ImagePullPolicy: corev1.PullAlways,
+					SecurityContext: podframework.GetRestrictedContainerSecurityContext(),
+				}},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			lastState = err.Error()
+			return false, nil
+		}
+		defer func() {
+			if err := podClient.Delete(context.Background(), pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &immediate}); err != nil {
+				e2e.Logf("Unable to delete canary pod %s/%s: %v", ns, pod.Name, err)
+			}
+		}()
+
+		// a pull failure, or a pod that does not start within a minute, is retried with a new canary
+		pulled := false
+		_ = pollUntilTimeout(ctx, 2*time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+			pod, err := podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			lastState = describePod(pod)
+			for _, status := range pod.Status.ContainerStatuses {
+				switch {
+				case status.State.Running != nil, status.State.Terminated != nil:
+					pulled = true
+					return true, nil
+				case status.State.Waiting != nil && (status.State.Waiting.Reason == "ErrImagePull" || status.State.Waiting.Reason == "ImagePullBackOff"):
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		return pulled, nil
+	})
+	if err != nil {
+		return fmt.Errorf("service account %s/%s could not pull %s: %w (last canary pod: %s)", ns, sa, privateImage, err, lastState)
+	}
+	return nil
+}
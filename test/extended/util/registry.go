@@ -0,0 +1,143 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	o "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	"k8s.io/kubernetes/test/e2e/framework/skipper"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	imageregistryclient "github.com/openshift/client-go/imageregistry/clientset/versioned"
+)
+
+const (
+	internalRegistryNamespace    = "openshift-image-registry"
+	internalRegistryDefaultRoute = "default-route"
+	internalRegistryServiceHost  = "image-registry.openshift-image-registry.svc:5000"
+)
+
+// IsInternalRegistryAvailable returns true if the ImageRegistry capability is enabled and
+// the image registry operator is configured to run the internal registry.
+func IsInternalRegistryAvailable(oc *CLI) (bool, error) {
+	enabled, err := HasCapability(oc, configv1.ClusterVersionCapabilityImageRegistry)
+	if err != nil || !enabled {
+		return false, err
+	}
+	client, err := imageregistryclient.NewForConfig(oc.AdminConfig())
+	if err != nil {
+		return false, err
+	}
+	config, err := client.ImageregistryV1().Configs().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failure getting the image registry config: %w", err)
+	}
+	return config.Spec.ManagementState != operatorv1.Removed, nil
+}
+
+// InternalRegistryHost returns the host to push to the internal registry from outside
+// the cluster if its default route is exposed, and the service host otherwise.
+func InternalRegistryHost(oc *CLI) (string, error) {
+	route, err := oc.AdminRouteClient().RouteV1().Routes(internalRegistryNamespace).Get(context.Background(), internalRegistryDefaultRoute, metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+	case err != nil:
+		return "", fmt.Errorf("failure getting the image registry default route: %w", err)
+	case len(route.Spec.Host) > 0:
+		return route.Spec.Host, nil
+	}
+
+	imageConfig, err := oc.AdminConfigClient().ConfigV1().Images().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return "", fmt.Errorf("failure getting the image config: %w", err)
+	}
+	if err == nil && len(imageConfig.Status.InternalRegistryHostname) > 0 {
+		return imageConfig.Status.InternalRegistryHostname, nil
+	}
+	return internalRegistryServiceHost, nil
+}
+
+// RegistryAuthForServiceAccount returns a docker config JSON, suitable for the --authfile
+// and --registry-config flags of podman and oc image, that authenticates to the internal
+// registry as the given service account of the test namespace.
+func RegistryAuthForServiceAccount(oc *CLI, sa string) ([]byte, error) {
+	host, err := InternalRegistryHost(oc)
+	if err != nil {
+		return nil, err
+	}
+	token, err := oc.AdminKubeClient().CoreV1().ServiceAccounts(oc.Namespace()).CreateToken(context.Background(), sa, &authenticationv1.TokenRequest{}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to request a token for service account %s/%s: %w", oc.Namespace(), sa, err)
+	}
+	return json.Marshal(credentialprovider.DockerConfigJSON{
+		Auths: credentialprovider.DockerConfig{
+			host: credentialprovider.DockerConfigEntry{
+				Username: "serviceaccount",
+				Password: token.Status.Token,
+			},
+		},
+	})
+}
+
+// SkipUnlessInternalRegistry skips the test if the internal registry is not available.
+func SkipUnlessInternalRegistry(oc *CLI) {
+	available, err := IsInternalRegistryAvailable(oc)
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the internal registry is available")
+	if !available {
+		skipper.Skipf("Skipping because the internal registry is not available")
+	}
+}
+
+// SkipUnlessInternalRegistryRoute skips the test if the default route of the internal
+// registry is not exposed.
+func SkipUnlessInternalRegistryRoute(oc *CLI) {
+	SkipUnlessInternalRegistry(oc)
+	_, err := oc.AdminRouteClient().RouteV1().Routes(internalRegistryNamespace).Get(context.Background(), internalRegistryDefaultRoute, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		skipper.Skipf("Skipping because the internal registry default route is not exposed")
+	}
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the internal registry default route is exposed")
+}
+
+// IsImageRegistryAvailable returns true if the internal registry is configured to run, as
+// IsInternalRegistryAvailable reports, and its deployment is available.
+func (c *CLI) IsImageRegistryAvailable() (bool, error) {
+	configured, err := IsInternalRegistryAvailable(c)
+	if err != nil || !configured {
+		return false, err
+	}
+	deployment, err := c.AdminKubeClient().AppsV1().Deployments(internalRegistryNamespace).Get(context.Background(), "image-registry", metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failure getting the image registry deployment: %w", err)
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+// SkipIfNoRegistry skips the test if the internal registry is removed or not available.
+func (c *CLI) SkipIfNoRegistry() {
+	available, err := c.IsImageRegistryAvailable()
+	o.ExpectWithOffset(1, err).NotTo(o.HaveOccurred(), "unable to determine whether the image registry is available")
+	if !available {
+		skipper.Skipf("Skipping because the image registry is not available")
+	}
+}
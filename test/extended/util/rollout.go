@@ -0,0 +1,216 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	oappsv1 "github.com/openshift/api/apps/v1"
+	appsv1client "github.com/openshift/client-go/apps/clientset/versioned"
+	"github.com/openshift/library-go/pkg/apps/appsutil"
+)
+
+// WaitForDeploymentComplete waits for the rollout of the Deployment to complete, using the
+// same checks as `oc rollout status`. On timeout the returned error includes the replica
+// counts, the conditions and the pods that are not ready.
+func WaitForDeploymentComplete(oc *CLI, ns, name string, timeout time.Duration) error {
+	var deployment *appsv1.Deployment
+	var status string
+	err := pollUntilTimeout(context.Background(), defaultPollingTime, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		deployment, err = oc.AdminKubeClient().AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			status = err.Error()
+			return false, nil
+		}
+		var done bool
+		done, status, err = deploymentRolloutDone(deployment)
+		return done, err
+	})
+	if err != nil {
+		details := status
+		if deployment != nil {
+			details += "; " + describeDeployment(deployment) + "; not ready pods: " + describeNotReadyPods(oc, ns, deployment.Spec.Selector)
+		}
+		return fmt.Errorf("deployment %s/%s did not complete: %w (%s)", ns, name, err, details)
+	}
+	e2e.Logf("Deployment %s/%s rolled out", ns, name)
+	return nil
+}
+
+// WaitForDeploymentConfigComplete waits, as the cluster admin, for the latest version of the
+// DeploymentConfig to be deployed and available, as CLI.WaitForDeploymentConfigComplete does.
+func WaitForDeploymentConfigComplete(oc *CLI, ns, name string, timeout time.Duration) error {
+	return oc.AsAdmin().WaitForDeploymentConfigComplete(ns, name, timeout)
+}
+
+// WaitForDeploymentConfigComplete waits, as the user of the CLI, for the latest version of the
+// DeploymentConfig to be deployed and available. On timeout the returned error includes the
+// replica counts, the conditions and the pods that are not ready, followed by the logs of the
+// deployer pod of the latest version.
+func (c *CLI) WaitForDeploymentConfigComplete(namespace, name string, timeout time.Duration) error {
+	err := waitForDeploymentConfigComplete(c, c.AppsClient(), namespace, name, timeout)
+	if err == nil {
+		return nil
+	}
+	dc, getErr := c.AppsClient().AppsV1().DeploymentConfigs(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if getErr != nil || dc.Status.LatestVersion == 0 {
+		return err
+	}
+	deployerPod := appsutil.DeployerPodNameForDeployment(appsutil.LatestDeploymentNameForConfig(dc))
+	logs, logsErr := PodLogs(c, namespace, deployerPod, "", PodLogOptions{})
+	if logsErr != nil {
+		return fmt.Errorf("%w\nunable to get logs of deployer pod %s: %v", err, deployerPod, logsErr)
+	}
+	return fmt.Errorf("%w\nlogs of deployer pod %s:\n%s", err, deployerPod, logs)
+}
+
+func waitForDeploymentConfigComplete(oc *CLI, client appsv1client.Interface, ns, name string, timeout time.Duration) error {
+	var dc *oappsv1.DeploymentConfig
+	var status string
+	err := pollUntilTimeout(context.Background(), defaultPollingTime, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		dc, err = client.AppsV1().DeploymentConfigs(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			status = err.Error()
+			return false, nil
+		}
+		var rc *corev1.ReplicationController
+		if dc.Status.LatestVersion > 0 {
+			rc, err = oc.AdminKubeClient().CoreV1().ReplicationControllers(ns).Get(ctx, appsutil.LatestDeploymentNameForConfig(dc), metav1.GetOptions{})
+			if err != nil {
+				status = err.Error()
+				return false, nil
+			}
+		}
+		var done bool
+		done, status, err = deploymentConfigRolloutDone(dc, rc)
+		return done, err
+	})
+	if err != nil {
+		details := status
+		if dc != nil {
+			details += "; " + describeDeploymentConfig(dc) + "; not ready pods: " +
+				describeNotReadyPods(oc, ns, &metav1.LabelSelector{MatchLabels: dc.Spec.Selector})
+		}
+		return fmt.Errorf("deploymentconfig %s/%s did not complete: %w (%s)", ns, name, err, details)
+	}
+	e2e.Logf("DeploymentConfig %s/%s rolled out version %d", ns, name, dc.Status.LatestVersion)
+	return nil
+}
+
+// deploymentRolloutDone mirrors the rollout status logic of kubectl.
+func deploymentRolloutDone(deployment *appsv1.Deployment) (bool, string, error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, "waiting for the deployment spec update to be observed", nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, "", fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name)
+		}
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	switch {
+	case deployment.Status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("%d out of %d new replicas have been updated", deployment.Status.UpdatedReplicas, replicas), nil
+	case deployment.Status.Replicas > deployment.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d old replicas are pending termination", deployment.Status.Replicas-deployment.Status.UpdatedReplicas), nil
+	case deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d of %d updated replicas are available", deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas), nil
+	}
+	return true, "rolled out", nil
+}
+
+// deploymentConfigRolloutDone checks that the latest deployment of the DeploymentConfig
+// completed and that its replicas are updated and available.
+func deploymentConfigRolloutDone(dc *oappsv1.DeploymentConfig, latest *corev1.ReplicationController) (bool, string, error) {
+	if dc.Generation > dc.Status.ObservedGeneration {
+		return false, "waiting for the deploymentconfig spec update to be observed", nil
+	}
+	if latest == nil {
+		return false, "waiting for the first deployment", nil
+	}
+	switch phase := appsutil.DeploymentStatusFor(latest); phase {
+	case oappsv1.DeploymentStatusFailed:
+		return false, "", fmt.Errorf("deployment %s failed: %s", latest.Name, appsutil.DeploymentStatusReasonFor(latest))
+	case oappsv1.DeploymentStatusComplete:
+	default:
+		return false, fmt.Sprintf("deployment %s is %s", latest.Name, phase), nil
+	}
+	switch {
+	case dc.Status.UpdatedReplicas < dc.Spec.Replicas:
+		return false, fmt.Sprintf("%d out of %d new replicas have been updated", dc.Status.UpdatedReplicas, dc.Spec.Replicas), nil
+	case dc.Status.AvailableReplicas < dc.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d of %d updated replicas are available", dc.Status.AvailableReplicas, dc.Status.UpdatedReplicas), nil
+	}
+	return true, "rolled out", nil
+}
+
+func describeDeployment(deployment *appsv1.Deployment) string {
+	var conditions []string
+	for _, condition := range deployment.Status.Conditions {
+		conditions = append(conditions, fmt.Sprintf("%s=%s (%s: %s)", condition.Type, condition.Status, condition.Reason, condition.Message))
+	}
+	return fmt.Sprintf("replicas=%d updated=%d ready=%d available=%d conditions=[%s]",
+		deployment.Status.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.ReadyReplicas, deployment.Status.AvailableReplicas, strings.Join(conditions, ", "))
+}
+
+func describeDeploymentConfig(dc *oappsv1.DeploymentConfig) string {
+	var conditions []string
+	for _, condition := range dc.Status.Conditions {
+		conditions = append(conditions, fmt.Sprintf("%s=%s (%s: %s)", condition.Type, condition.Status, condition.Reason, condition.Message))
+	}
+	return fmt.Sprintf("latestVersion=%d replicas=%d updated=%d ready=%d available=%d conditions=[%s]",
+		dc.Status.LatestVersion, dc.Status.Replicas, dc.Status.UpdatedReplicas, dc.Status.ReadyReplicas, dc.Status.AvailableReplicas, strings.Join(conditions, ", "))
+}
+
+// describeNotReadyPods lists the pods matching the selector that are not ready, with their container states.
+func describeNotReadyPods(oc *CLI, ns string, selector *metav1.LabelSelector) string {
+	return describePodsBehind(oc, ns, selector, nil)
+}
+
+// describePodsBehind lists the pods matching the selector that are not ready or, when upToDate is
+// set, do not run the current revision, with their node and container states.
+func describePodsBehind(oc *CLI, ns string, selector *metav1.LabelSelector, upToDate func(*corev1.Pod) bool) string {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || labelSelector.Empty() {
+		labelSelector = labels.Everything()
+	}
+	pods, err := oc.AdminKubeClient().CoreV1().Pods(ns).List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return fmt.Sprintf("<unable to list pods: %v>", err)
+	}
+	var behind []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		switch {
+		case upToDate != nil && !upToDate(pod):
+			behind = append(behind, fmt.Sprintf("%s on node %q (old revision): %s", pod.Name, pod.Spec.NodeName, describePod(pod)))
+		case !isPodReady(pod):
+			behind = append(behind, fmt.Sprintf("%s on node %q: %s", pod.Name, pod.Spec.NodeName, describePod(pod)))
+		}
+	}
+	if len(behind) == 0 {
+		return "<none>"
+	}
+	return strings.Join(behind, "; ")
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
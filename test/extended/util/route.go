@@ -0,0 +1,245 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+// ProbeOptions configures ProbeRoute.
+type ProbeOptions struct {
+	// Timeout is the total deadline for the probe, including retries. Defaults to 2 minutes.
+	Timeout time.Duration
+	// TrustRouterCA verifies edge and reencrypt routes against the default ingress CA
+	// instead of skipping certificate verification. It is ignored for passthrough routes,
+	// which are served with the backend's certificate.
+	TrustRouterCA bool
+	// Address optionally overrides the resolution of the route host, e.g. to connect
+	// to a specific router IP. It may include a port.
+	Address string
+}
+
+// WaitForRouteAdmitted waits until every router that reported on the route has admitted it,
+// and returns the admitted route. It fails early when a router rejects the route.
+func WaitForRouteAdmitted(oc *CLI, ns, name string, timeout time.Duration) (*routev1.Route, error) {
+	var route *routev1.Route
+	var status string
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		route, err = oc.AdminRouteClient().RouteV1().Routes(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			status = err.Error()
+			return false, nil
+		}
+		var admitted bool
+		admitted, status, err = routeAdmitted(route)
+		return admitted, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("route %s/%s was not admitted: %w (%s)", ns, name, err, status)
+	}
+	return route, nil
+}
+
+// routeAdmitted returns true when at least one router reported on the route and all of them
+// admitted it, and an error when a router rejected it.
+func routeAdmitted(route *routev1.Route) (bool, string, error) {
+	if len(route.Status.Ingress) == 0 {
+		return false, "no router has reported on the route", nil
+	}
+	for _, ingress := range route.Status.Ingress {
+		var admitted *routev1.RouteIngressCondition
+		for i := range ingress.Conditions {
+			if ingress.Conditions[i].Type == routev1.RouteAdmitted {
+				admitted = &ingress.Conditions[i]
+			}
+		}
+		switch {
+		case admitted == nil:
+			return false, fmt.Sprintf("router %q has not admitted the route yet", ingress.RouterName), nil
+		case admitted.Status == "False":
+			return false, "", fmt.Errorf("router %q rejected the route: %s: %s", ingress.RouterName, admitted.Reason, admitted.Message)
+		case admitted.Status != "True":
+			return false, fmt.Sprintf("router %q reports Admitted=%s", ingress.RouterName, admitted.Status), nil
+		}
+	}
+	return true, "admitted", nil
+}
+
+// ExposeService creates an edge terminated route to the port of the service, waits for it to be
+// admitted and returns its https:// URL. The route is deleted when the test ends. Use
+// RouteHTTPClient to reach the URL without skipping certificate verification.
+func (c *CLI) ExposeService(namespace, service string, port int32) (string, error) {
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service,
+			Namespace: namespace,
+		},
+		Spec: routev1.RouteSpec{
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: service},
+			Port: &routev1.RoutePort{TargetPort: intstr.FromInt32(port)},
+			TLS: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationEdge,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			},
+		},
+	}
+	route, err := c.AdminRouteClient().RouteV1().Routes(namespace).Create(context.Background(), route, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to create a route for service %s/%s: %w", namespace, service, err)
+	}
+	c.AddResourceToDelete(routev1.GroupVersion.WithResource("routes"), route)
+
+	route, err = WaitForRouteAdmitted(c, namespace, route.Name, 2*time.Minute)
+	if err != nil {
+		return "", err
+	}
+	return "https://" + routeHost(route), nil
+}
+
+// ProbeRoute sends a GET for path through the route and returns the first response that is not
+// a 503, which the router serves while the route is propagating. Connection errors are also
+// retried until the deadline. The caller must close the response body. The route host is always
+// sent as SNI, so passthrough routes reach the right backend even when Address is set.
+func ProbeRoute(oc *CLI, route *routev1.Route, path string, opts ProbeOptions) (*http.Response, error) {
+	host := routeHost(route)
+	if len(host) == 0 {
+		return nil, fmt.Errorf("route %s/%s has no host", route.Namespace, route.Name)
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Minute
+	}
+
+	scheme := "http"
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: true}
+	if route.Spec.TLS != nil {
+		scheme = "https"
+		if opts.TrustRouterCA && route.Spec.TLS.Termination != routev1.TLSTerminationPassthrough {
+			rootCAs, err := oc.defaultIngressCAs()
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig = &tls.Config{ServerName: host, RootCAs: rootCAs}
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if len(opts.Address) > 0 {
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			target := opts.Address
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				_, port, _ := net.SplitHostPort(addr)
+				target = net.JoinHostPort(target, port)
+			}
+			return dialer.DialContext(ctx, network, target)
+		}
+	}
+
+	url := fmt.Sprintf("%s://%s/%s", scheme, host, strings.TrimPrefix(path, "/"))
+	deadline := time.Now().Add(opts.Timeout)
+	var lastErr error
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("probing %s did not succeed within %s: %w", url, opts.Timeout, lastErr)
+		}
+		client := &http.Client{Transport: transport, Timeout: min(remaining, 30*time.Second)}
+		resp, err := client.Get(url)
+		switch {
+		case err != nil:
+			lastErr = err
+		case resp.StatusCode == http.StatusServiceUnavailable:
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("got %s", resp.Status)
+		default:
+			return resp, nil
+		}
+		e2e.Logf("Probing %s: %v, retrying", url, lastErr)
+		time.Sleep(min(2*time.Second, time.Until(deadline)))
+	}
+}
+
+// WaitForStableConnectivity sends a GET to url every interval with RouteHTTPClient until
+// successesRequired consecutive requests got a 2xx response, so that an endpoint that is still
+// flapping, e.g. while routers or load balancers converge, is not mistaken for a ready one. Any
+// failure restarts the count. On timeout the returned error reports the longest streak and the
+// last failure.
+func (c *CLI) WaitForStableConnectivity(url string, successesRequired int, interval, timeout time.Duration) error {
+	client, err := c.RouteHTTPClient()
+	if err != nil {
+		return err
+	}
+	return waitForStableConnectivity(client, url, successesRequired, interval, timeout)
+}
+
+func waitForStableConnectivity(client *http.Client, url string, successesRequired int, interval, timeout time.Duration) error {
+	var consecutive, longest, failures int
+	var lastFailure error
+	err := pollUntilTimeout(context.Background(), interval, timeout, true, func(pollCtx context.Context) (bool, error) {
+		ctx, cancel := context.WithTimeout(pollCtx, max(interval, 10*time.Second))
+		defer cancel()
+		err := getSucceeds(ctx, client, url)
+		if pollCtx.Err() != nil {
+			// the probe was cut off by the timeout, it tells nothing about the endpoint
+			return false, nil
+		}
+		if err != nil {
+			if consecutive > 0 {
+				e2e.Logf("Probing %s failed after %d consecutive successes: %v", url, consecutive, err)
+			}
+			consecutive = 0
+			failures++
+			lastFailure = err
+			return false, nil
+		}
+		consecutive++
+		longest = max(longest, consecutive)
+		return consecutive >= successesRequired, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s did not respond successfully %d consecutive times, the longest streak was %d with %d failures (last: %v): %w",
+			url, successesRequired, longest, failures, lastFailure, err)
+	}
+	e2e.Logf("%s responded successfully %d consecutive times", url, successesRequired)
+	return nil
+}
+
+// getSucceeds sends a GET to url and returns an error unless the response is a 2xx.
+func getSucceeds(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("got %s", resp.Status)
+	}
+	return nil
+}
+
+// routeHost returns the host the first router exposes the route under, falling back to spec.host.
+func routeHost(route *routev1.Route) string {
+	if len(route.Status.Ingress) > 0 && len(route.Status.Ingress[0].Host) > 0 {
+		return route.Status.Ingress[0].Host
+	}
+	return route.Spec.Host
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRouteAdmitted(t *testing.T) {
+	ingress := func(router string, status corev1.ConditionStatus) routev1.RouteIngress {
+		ingress := routev1.RouteIngress{RouterName: router}
+		if len(status) > 0 {
+			ingress.Conditions = []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: status, Reason: "HostAlreadyClaimed"}}
+		}
+		return ingress
+	}
+
+	tests := []struct {
+		name         string
+		ingresses    []routev1.RouteIngress
+		wantAdmitted bool
+		wantErr      bool
+	}{
+		{
+			name: "no routers",
+		},
+		{
+			name:         "admitted by the only router",
+			ingresses:    []routev1.RouteIngress{ingress("default", corev1.ConditionTrue)},
+			wantAdmitted: true,
+		},
+		{
+			name:      "pending on one router",
+			ingresses: []routev1.RouteIngress{ingress("default", corev1.ConditionTrue), ingress("sharded", "")},
+		},
+		{
+			name:      "rejected by one router",
+			ingresses: []routev1.RouteIngress{ingress("default", corev1.ConditionTrue), ingress("sharded", corev1.ConditionFalse)},
+			wantErr:   true,
+		},
+		{
+			name:         "admitted by all routers",
+			ingresses:    []routev1.RouteIngress{ingress("default", corev1.ConditionTrue), ingress("sharded", corev1.ConditionTrue)},
+			wantAdmitted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &routev1.Route{Status: routev1.RouteStatus{Ingress: tt.ingresses}}
+			admitted, _, err := routeAdmitted(route)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if admitted != tt.wantAdmitted {
+				t.Errorf("expected admitted=%v, got %v", tt.wantAdmitted, admitted)
+			}
+		})
+	}
+}
+
+func TestWaitForStableConnectivity(t *testing.T) {
+	// the endpoint flaps for its first requests, then is stable
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := requests.Add(1); {
+		case n == 2 || n == 4:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	if err := waitForStableConnectivity(server.Client(), server.URL, 3, time.Millisecond, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// successes at requests 1, 3 and 5 are not consecutive
+	if got := requests.Load(); got != 7 {
+		t.Errorf("expected 7 requests to observe 3 consecutive successes, got %d", got)
+	}
+
+	flapping := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer flapping.Close()
+	err := waitForStableConnectivity(flapping.Client(), flapping.URL, 2, time.Millisecond, 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "the longest streak was 1") || !strings.Contains(err.Error(), "502 Bad Gateway") {
+		t.Errorf("expected an error describing the flapping endpoint, got %v", err)
+	}
+}
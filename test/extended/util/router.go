@@ -2,6 +2,10 @@ package util
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
 	"time"
 
 	o "github.com/onsi/gomega"
@@ -9,7 +13,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kapierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 func WaitForRouterInternalIP(oc *CLI) (string, error) {
@@ -22,7 +25,7 @@ func waitForRouterExternalIP(oc *CLI) (string, error) {
 
 func routerShouldHaveExternalService(oc *CLI) (bool, error) {
 	foundLoadBalancerServiceStrategyType := false
-	err := wait.PollImmediate(2*time.Second, 30*time.Second, func() (bool, error) {
+	err := poll(2*time.Second, 30*time.Second, true, func() (bool, error) {
 		ic, err := oc.AdminOperatorClient().OperatorV1().IngressControllers("openshift-ingress-operator").Get(context.Background(), "default", metav1.GetOptions{})
 		if kapierrs.IsNotFound(err) {
 			return false, nil
@@ -56,7 +59,7 @@ func waitForNamedRouterServiceIP(oc *CLI, name string) (string, error) {
 
 	// wait for the service to show up
 	var endpoint string
-	err = wait.PollImmediate(2*time.Second, 60*time.Second, func() (bool, error) {
+	err = poll(2*time.Second, 60*time.Second, true, func() (bool, error) {
 		svc, err := oc.AdminKubeClient().CoreV1().Services(ns).Get(context.Background(), name, metav1.GetOptions{})
 		if kapierrs.IsNotFound(err) {
 			return false, nil
@@ -80,3 +83,66 @@ func waitForNamedRouterServiceIP(oc *CLI, name string) (string, error) {
 	})
 	return endpoint, err
 }
+
+// IngressDomain returns the default apps domain of the cluster from the Ingress config object.
+func (c *CLI) IngressDomain() (string, error) {
+	ingress, err := c.AdminConfigClient().ConfigV1().Ingresses().Get(context.Background(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if len(ingress.Spec.Domain) == 0 {
+		return "", fmt.Errorf("the cluster Ingress config has no spec.domain")
+	}
+	return ingress.Spec.Domain, nil
+}
+
+// AppHostname returns the hostname made of prefix and the default apps domain.
+func (c *CLI) AppHostname(prefix string) string {
+	domain, err := c.IngressDomain()
+	o.Expect(err).NotTo(o.HaveOccurred())
+	return prefix + "." + domain
+}
+
+// ClusterIngressDomain returns the default apps domain of the cluster from the Ingress config object.
+func ClusterIngressDomain(oc *CLI) (string, error) {
+	return oc.IngressDomain()
+}
+
+// DefaultRouteHost returns the host the default router assigns to a route
+// without an explicit host, i.e. <name>-<namespace>.<apps domain>.
+func DefaultRouteHost(name, ns string, oc *CLI) string {
+	domain, err := ClusterIngressDomain(oc)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	return fmt.Sprintf("%s-%s.%s", name, ns, domain)
+}
+
+// RouteHTTPClient returns an HTTP client that trusts the default ingress CA published
+// in the default-ingress-cert configmap of openshift-config-managed, so that tests can
+// reach routes and the console without skipping certificate verification.
+func (c *CLI) RouteHTTPClient() (*http.Client, error) {
+	rootCAs, err := c.defaultIngressCAs()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	return &http.Client{Transport: transport}, nil
+}
+
+// defaultIngressCAs returns a pool holding the default ingress CA bundle.
+func (c *CLI) defaultIngressCAs() (*x509.CertPool, error) {
+	cm, err := c.AdminKubeClient().CoreV1().ConfigMaps("openshift-config-managed").Get(context.Background(), "default-ingress-cert", metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the default ingress CA: %w", err)
+	}
+	caBundle, ok := cm.Data["ca-bundle.crt"]
+	if !ok {
+		return nil, fmt.Errorf("no ca-bundle.crt found in openshift-config-managed/default-ingress-cert")
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, fmt.Errorf("no certificates found in openshift-config-managed/default-ingress-cert")
+	}
+	return rootCAs, nil
+}
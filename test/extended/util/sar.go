@@ -7,13 +7,12 @@ import (
 
 	authorizationapiv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	kclientset "k8s.io/client-go/kubernetes"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
 func WaitForSelfSAR(interval, timeout time.Duration, c kclientset.Interface, selfSAR authorizationapiv1.SelfSubjectAccessReviewSpec) error {
-	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+	err := poll(interval, timeout, true, func() (bool, error) {
 		res, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(),
 			&authorizationapiv1.SelfSubjectAccessReview{
 				Spec: selfSAR,
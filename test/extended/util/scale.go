@@ -0,0 +1,59 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// ScaleAndWait sets the replicas of the object through its scale subresource and waits for the
+// replicas to be created and ready. Any resource with a scale subresource and a readyReplicas
+// status works, e.g. deployments, replicasets, statefulsets, deploymentconfigs and machinesets.
+// On timeout the returned error reports how far the replicas are from the target and the events
+// of the object, which show e.g. quota or HPA conflicts.
+func ScaleAndWait(oc *CLI, gr schema.GroupResource, ns, name string, replicas int32, timeout time.Duration) error {
+	mapper := oc.AsAdmin().RESTMapper()
+	gvr, err := mapper.ResourceFor(gr.WithVersion(""))
+	if err != nil {
+		return fmt.Errorf("unable to map %s: %w", gr, err)
+	}
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return fmt.Errorf("unable to map %s: %w", gr, err)
+	}
+	client := oc.AdminDynamicClient().Resource(gvr).Namespace(ns)
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	if _, err := client.Patch(context.Background(), name, types.MergePatchType, patch, metav1.PatchOptions{}, "scale"); err != nil {
+		return fmt.Errorf("unable to scale %s %s/%s to %d: %w", gr, ns, name, replicas, err)
+	}
+
+	var current, ready int64
+	err = pollUntilTimeout(context.Background(), defaultPollingTime, timeout, true, func(ctx context.Context) (bool, error) {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			e2e.Logf("Unable to get %s %s/%s: %v", gr, ns, name, err)
+			return false, nil
+		}
+		generation := obj.GetGeneration()
+		observedGeneration, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+		if found && observedGeneration < generation {
+			return false, nil
+		}
+		current, _, _ = unstructured.NestedInt64(obj.Object, "status", "replicas")
+		ready, _, _ = unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		return current == int64(replicas) && ready == int64(replicas), nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s %s/%s did not scale to %d: %w (replicas=%d ready=%d, %d ready replicas missing; events: %s)",
+			gr, ns, name, replicas, err, current, ready, int64(replicas)-ready, describeEventsFor(oc, ns, gvk.Kind, name))
+	}
+	e2e.Logf("Scaled %s %s/%s to %d", gr, ns, name, replicas)
+	return nil
+}
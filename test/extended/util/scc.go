@@ -0,0 +1,109 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	securityv1 "github.com/openshift/api/security/v1"
+)
+
+// GrantSCCToServiceAccount allows the service account of the test namespace to use the
+// SecurityContextConstraints.
+func (c *CLI) GrantSCCToServiceAccount(scc, sa string) error {
+	return c.GrantSCCToServiceAccountInNamespace(scc, c.Namespace(), sa)
+}
+
+// GrantSCCToServiceAccountInNamespace allows the service account of the namespace to use
+// the SecurityContextConstraints through a role granting the use verb on it. The role and
+// its binding are deleted with the test namespace.
+func (c *CLI) GrantSCCToServiceAccountInNamespace(scc, namespace, sa string) error {
+	ctx := context.Background()
+	if _, err := c.AdminSecurityClient().SecurityV1().SecurityContextConstraints().Get(ctx, scc, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("unable to get SCC %q: %w", scc, err)
+	}
+
+	role, err := c.AdminKubeClient().RbacV1().Roles(namespace).Create(ctx, &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "use-scc-" + scc + "-"},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"use"},
+				APIGroups:     []string{securityv1.GroupName},
+				Resources:     []string{"securitycontextconstraints"},
+				ResourceNames: []string{scc},
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create a role to use SCC %q: %w", scc, err)
+	}
+	c.AddResourceToDelete(rbacv1.SchemeGroupVersion.WithResource("roles"), role)
+
+	roleBinding, err := c.AdminKubeClient().RbacV1().RoleBindings(namespace).Create(ctx, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: role.Name},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: sa},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to bind SCC %q to service account %s/%s: %w", scc, namespace, sa, err)
+	}
+	c.AddResourceToDelete(rbacv1.SchemeGroupVersion.WithResource("rolebindings"), roleBinding)
+
+	e2e.Logf("Granted SCC %q to service account %s/%s", scc, namespace, sa)
+	return nil
+}
+
+// PodAssignedSCC returns the SCC that admitted the pod, from its openshift.io/scc annotation.
+func (c *CLI) PodAssignedSCC(namespace, pod string) (string, error) {
+	p, err := c.AdminKubeClient().CoreV1().Pods(namespace).Get(context.Background(), pod, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return podAssignedSCC(p)
+}
+
+// WaitForPodWithSCC waits for the pod to be running and checks that it was admitted by the SCC.
+// Since the SCC is assigned when the pod is created, a pod admitted by another SCC is an error
+// right away.
+func (c *CLI) WaitForPodWithSCC(namespace, pod, scc string, timeout time.Duration) error {
+	err := c.WaitForPodCondition(namespace, pod, func(p *corev1.Pod) (bool, error) {
+		assigned, err := podAssignedSCC(p)
+		if err != nil {
+			return false, err
+		}
+		if assigned != scc {
+			return false, fmt.Errorf("pod %s/%s was admitted by SCC %q instead of %q", namespace, pod, assigned, scc)
+		}
+		switch p.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("pod %s/%s completed with phase %s", namespace, pod, p.Status.Phase)
+		}
+		return false, nil
+	}, timeout)
+	if err != nil {
+		return err
+	}
+	e2e.Logf("Pod %s/%s is running with SCC %q", namespace, pod, scc)
+	return nil
+}
+
+func podAssignedSCC(pod *corev1.Pod) (string, error) {
+	scc, ok := pod.Annotations[securityv1.ValidatedSCCAnnotation]
+	if !ok {
+		return "", fmt.Errorf("pod %s/%s has no %s annotation", pod.Namespace, pod.Name, securityv1.ValidatedSCCAnnotation)
+	}
+	return scc, nil
+}
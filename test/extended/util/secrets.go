@@ -0,0 +1,151 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// maxObjectDataSize is the size limit etcd puts on a Secret or ConfigMap, with some room for
+// the metadata of the object.
+const maxObjectDataSize = 1024*1024 - 16*1024
+
+// CreateSecret creates a Secret of the given type holding data in the current namespace. The
+// Secret is deleted when the test ends. Data too large to be stored is rejected with a clear
+// error instead of an obscure failure from the API server.
+func CreateSecret(oc *CLI, name string, data map[string][]byte, secretType corev1.SecretType) (*corev1.Secret, error) {
+	if err := checkObjectDataSize("secret", name, data, nil); err != nil {
+		return nil, err
+	}
+	secret, err := oc.AdminKubeClient().CoreV1().Secrets(oc.Namespace()).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Data:       data,
+		Type:       secretType,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	oc.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("secrets"), secret)
+	return secret, nil
+}
+
+// CreateConfigMapFromDir creates a ConfigMap in the current namespace with a key for each regular
+// file of dir, like `oc create configmap --from-file=<dir>`. Files that are not valid UTF-8 are
+// stored as binary data. The ConfigMap is deleted when the test ends.
+func CreateConfigMapFromDir(oc *CLI, name, dir string) (*corev1.ConfigMap, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Data:       map[string]string{},
+		BinaryData: map[string][]byte{},
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if utf8.Valid(content) {
+			cm.Data[entry.Name()] = string(content)
+		} else {
+			cm.BinaryData[entry.Name()] = content
+		}
+	}
+	if err := checkObjectDataSize("configmap", name, cm.BinaryData, cm.Data); err != nil {
+		return nil, err
+	}
+
+	cm, err = oc.AdminKubeClient().CoreV1().ConfigMaps(oc.Namespace()).Create(context.Background(), cm, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	oc.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("configmaps"), cm)
+	return cm, nil
+}
+
+// UpdateSecretAndWaitForPodRestart replaces the data of the Secret in the current namespace and
+// waits for every pod matching podSelector to have restarted a container or to have been replaced,
+// and for the pods to be ready again. It suits tests validating that secret rotation is picked up.
+func UpdateSecretAndWaitForPodRestart(oc *CLI, name string, data map[string][]byte, podSelector string, timeout time.Duration) error {
+	if err := checkObjectDataSize("secret", name, data, nil); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	podClient := oc.AdminKubeClient().CoreV1().Pods(oc.Namespace())
+	pods, err := podClient.List(ctx, metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		return err
+	}
+	before := map[string]int32{}
+	for _, pod := range pods.Items {
+		before[pod.Name] = totalRestarts(&pod)
+	}
+
+	secret, err := oc.AdminKubeClient().CoreV1().Secrets(oc.Namespace()).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	secret.Data = data
+	secret.StringData = nil
+	if _, err := oc.AdminKubeClient().CoreV1().Secrets(oc.Namespace()).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update secret %s/%s: %w", oc.Namespace(), name, err)
+	}
+
+	var pending []string
+	err = pollUntilTimeout(ctx, defaultPollingTime, timeout, true, func(ctx context.Context) (bool, error) {
+		pods, err := podClient.List(ctx, metav1.ListOptions{LabelSelector: podSelector})
+		if err != nil {
+			e2e.Logf("Unable to list pods: %v", err)
+			return false, nil
+		}
+		pending = nil
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if restarts, existed := before[pod.Name]; existed && totalRestarts(pod) <= restarts {
+				pending = append(pending, pod.Name+" has not restarted")
+			} else if !isPodReady(pod) {
+				pending = append(pending, pod.Name+" is not ready: "+describePod(pod))
+			}
+		}
+		return len(pods.Items) > 0 && len(pending) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("pods matching %q did not restart after updating secret %s/%s: %w (%v)", podSelector, oc.Namespace(), name, err, pending)
+	}
+	return nil
+}
+
+func totalRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// checkObjectDataSize returns an error if the data cannot be stored in a single object.
+func checkObjectDataSize(kind, name string, binaryData map[string][]byte, stringData map[string]string) error {
+	size := 0
+	for key, value := range binaryData {
+		size += len(key) + len(value)
+	}
+	for key, value := range stringData {
+		size += len(key) + len(value)
+	}
+	if size > maxObjectDataSize {
+		return fmt.Errorf("%s %s holds %d bytes of data, more than the %d bytes that fit in an object", kind, name, size, maxObjectDataSize)
+	}
+	return nil
+}
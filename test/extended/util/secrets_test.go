@@ -0,0 +1,16 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckObjectDataSize(t *testing.T) {
+	if err := checkObjectDataSize("secret", "small", map[string][]byte{"tls.crt": make([]byte, 4096)}, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := checkObjectDataSize("configmap", "large", map[string][]byte{"blob": make([]byte, 600*1024)}, map[string]string{"text": strings.Repeat("a", 600*1024)})
+	if err == nil || !strings.Contains(err.Error(), "configmap large holds") {
+		t.Errorf("expected a size error, got %v", err)
+	}
+}
@@ -2,11 +2,18 @@ package util
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 	kutilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
@@ -20,7 +27,7 @@ func RemoveStatefulSets(oc *CLI, sets ...string) error {
 			errs = append(errs, err)
 		}
 
-		err := wait.PollImmediate(5*time.Second, 5*time.Minute, func() (bool, error) {
+		err := poll(5*time.Second, 5*time.Minute, true, func() (bool, error) {
 			pods, err := GetStatefulSetPods(oc, set)
 			if err != nil {
 				e2e.Logf("Unable to get pods for statefulset/%s: %v", set, err)
@@ -46,3 +53,75 @@ func RemoveStatefulSets(oc *CLI, sets ...string) error {
 
 	return nil
 }
+
+// WaitForStatefulSetReady waits for all the replicas of the StatefulSet to be ready and to run the
+// current revision. A StatefulSet scaled to zero is ready once its status reports no replicas. On
+// timeout the returned error lists the pods that are behind and their nodes.
+func WaitForStatefulSetReady(oc *CLI, ns, name string, timeout time.Duration) error {
+	client := oc.AdminKubeClient().AppsV1().StatefulSets(ns)
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return client.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return client.Watch(ctx, options)
+		},
+	}
+
+	var set *appsv1.StatefulSet
+	var status string
+	_, err := watchtools.UntilWithSync(ctx, lw, &appsv1.StatefulSet{}, nil, func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			set = event.Object.(*appsv1.StatefulSet)
+			var done bool
+			done, status = statefulSetReady(set)
+			return done, nil
+		case watch.Deleted:
+			return false, fmt.Errorf("statefulset %s/%s was deleted", ns, name)
+		}
+		return false, nil
+	})
+	if err != nil {
+		if set == nil {
+			return fmt.Errorf("statefulset %s/%s was not ready: %w (statefulset not observed)", ns, name, err)
+		}
+		updateRevision := set.Status.UpdateRevision
+		return fmt.Errorf("statefulset %s/%s was not ready: %w (%s; pods behind: %s)", ns, name, err, status,
+			describePodsBehind(oc, ns, set.Spec.Selector, func(pod *corev1.Pod) bool {
+				return len(updateRevision) == 0 || pod.Labels[appsv1.StatefulSetRevisionLabel] == updateRevision
+			}))
+	}
+	e2e.Logf("StatefulSet %s/%s is ready", ns, name)
+	return nil
+}
+
+// statefulSetReady returns true once the replicas of the StatefulSet are ready at the current
+// revision. The revision is not checked for the OnDelete strategy, which only updates pods as
+// they are deleted.
+func statefulSetReady(set *appsv1.StatefulSet) (bool, string) {
+	replicas := int32(1)
+	if set.Spec.Replicas != nil {
+		replicas = *set.Spec.Replicas
+	}
+	status := fmt.Sprintf("replicas=%d ready=%d updated=%d currentRevision=%q updateRevision=%q",
+		replicas, set.Status.ReadyReplicas, set.Status.UpdatedReplicas, set.Status.CurrentRevision, set.Status.UpdateRevision)
+	switch {
+	case set.Status.ObservedGeneration < set.Generation:
+		return false, "waiting for the statefulset spec update to be observed"
+	case replicas == 0:
+		return set.Status.Replicas == 0, status
+	case set.Status.ReadyReplicas != replicas:
+		return false, status
+	case set.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType:
+		return true, status
+	}
+	return set.Status.UpdatedReplicas == replicas && set.Status.CurrentRevision == set.Status.UpdateRevision, status
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	podframework "k8s.io/kubernetes/test/e2e/framework/pod"
+
+	"github.com/openshift/origin/test/extended/util/image"
+)
+
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// DefaultStorageClass returns the default StorageClass of the cluster, or an error if
+// there is none.
+func DefaultStorageClass(oc *CLI) (*storagev1.StorageClass, error) {
+	storageClasses, err := oc.AdminKubeClient().StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list storage classes: %w", err)
+	}
+	for i := range storageClasses.Items {
+		sc := &storageClasses.Items[i]
+		if sc.Annotations[defaultStorageClassAnnotation] == "true" || sc.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+			return sc, nil
+		}
+	}
+	return nil, fmt.Errorf("no default storage class found among %d storage classes", len(storageClasses.Items))
+}
+
+// HasDefaultStorageClass returns true if the cluster has a default StorageClass.
+func HasDefaultStorageClass(oc *CLI) bool {
+	_, err := DefaultStorageClass(oc)
+	return err == nil
+}
+
+// CreatePVCAndWaitBound creates a PVC of the given size in the test namespace using the
+// default StorageClass and waits for it to be bound. If the StorageClass binds volumes on
+// first consumer, a pod mounting the claim is created as well. On timeout the returned
+// error includes the events of the claim.
+func CreatePVCAndWaitBound(oc *CLI, name string, size string, timeout time.Duration) (*corev1.PersistentVolumeClaim, error) {
+	sc, err := DefaultStorageClass(oc)
+	if err != nil {
+		return nil, err
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PVC size %q: %w", size, err)
+	}
+
+	ctx := context.Background()
+	namespace := oc.Namespace()
+	client := oc.AdminKubeClient()
+
+	pvc, err := client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: quantity},
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	oc.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims"), pvc)
+
+	if sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+		e2e.Logf("Storage class %s binds on first consumer, creating a pod to consume PVC %s/%s", sc.Name, namespace, name)
+		pod, err := client.CoreV1().Pods(namespace).Create(ctx, pvcConsumerPod(name), metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		oc.AddResourceToDelete(corev1.SchemeGroupVersion.WithResource("pods"), pod)
+	}
+
+	err = pollUntilTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		pvc, err = client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return pvc.Status.Phase == corev1.ClaimBound, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PVC %s/%s was not bound: %w (events: %s)", namespace, name, err, describeEventsFor(oc, namespace, "PersistentVolumeClaim", name))
+	}
+	return pvc, nil
+}
+
+// pvcConsumerPod returns a pod that mounts the claim so that it gets bound.
+func pvcConsumerPod(claimName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: claimName + "-consumer-"},
+		Spec: corev1.PodSpec{
+			SecurityContext: podframework.GetRestrictedPodSecurityContext(),
+			Containers: []corev1.Container{
+				{
+					Name:            "consumer",
+					Image:           image.ShellImage(),
+					Command:         []string{"sh", "-c", "trap exit TERM; while true; do sleep 5; done"},
+					SecurityContext: podframework.GetRestrictedContainerSecurityContext(),
+					VolumeMounts:    []corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+					},
+				},
+			},
+		},
+	}
+}
+
+// describeEventsFor returns the events of the object for use in error messages.
+func describeEventsFor(oc *CLI, namespace, kind, name string) string {
+	events, err := oc.AdminKubeClient().CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"involvedObject.kind": kind, "involvedObject.name": name}).String(),
+	})
+	if err != nil {
+		return fmt.Sprintf("<unable to list events: %v>", err)
+	}
+	if len(events.Items) == 0 {
+		return "<none>"
+	}
+	var summary []string
+	for _, event := range events.Items {
+		summary = append(summary, fmt.Sprintf("%s %s: %s", event.Type, event.Reason, event.Message))
+	}
+	return strings.Join(summary, "; ")
+}
@@ -0,0 +1,136 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+// ProcessTemplate processes a template with the template API, like `oc process`, and returns the
+// resulting objects as unstructured objects along with the value of every parameter, including
+// the ones generated from an expression. templateNameOrFile is either a file holding the template
+// or the name of a template in the current namespace, optionally as <namespace>/<name>. Setting
+// a parameter the template does not define is an error.
+func ProcessTemplate(oc *CLI, templateNameOrFile string, params map[string]string) ([]runtime.Object, map[string]string, error) {
+	template, err := loadTemplate(oc, templateNameOrFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := setTemplateParameters(template, params); err != nil {
+		return nil, nil, fmt.Errorf("unable to process template %s: %w", templateNameOrFile, err)
+	}
+
+	namespace := oc.Namespace()
+	if len(template.Namespace) > 0 {
+		namespace = template.Namespace
+	}
+	processed := &templatev1.Template{}
+	err = oc.TemplateClient().TemplateV1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("processedtemplates").
+		Body(template).
+		Do(context.Background()).
+		Into(processed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to process template %s: %w", templateNameOrFile, err)
+	}
+
+	var objects []runtime.Object
+	for _, raw := range processed.Objects {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw.Raw); err != nil {
+			return nil, nil, fmt.Errorf("unable to decode an object of template %s: %w", templateNameOrFile, err)
+		}
+		objects = append(objects, obj)
+	}
+	values := map[string]string{}
+	for _, param := range processed.Parameters {
+		values[param.Name] = param.Value
+	}
+	return objects, values, nil
+}
+
+// CreateFromTemplate processes the template like ProcessTemplate and creates the resulting objects,
+// which are deleted when the test ends. Objects without a namespace are created in the current
+// namespace. It returns the created objects and the value of every parameter.
+func CreateFromTemplate(oc *CLI, templateNameOrFile string, params map[string]string) ([]runtime.Object, map[string]string, error) {
+	objects, values, err := ProcessTemplate(oc, templateNameOrFile, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapper := oc.AsAdmin().RESTMapper()
+	dynamicClient := oc.AdminDynamicClient()
+	var created []runtime.Object
+	for _, object := range objects {
+		obj := object.(*unstructured.Unstructured)
+		resource, err := resourceFor(oc, mapper, obj)
+		if err != nil {
+			return created, values, fmt.Errorf("unable to map %s from template %s: %w", obj.GroupVersionKind(), templateNameOrFile, err)
+		}
+		result, err := dynamicClient.Resource(resource).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		if err != nil {
+			return created, values, fmt.Errorf("unable to create %s %s from template %s: %w", obj.GetKind(), obj.GetName(), templateNameOrFile, err)
+		}
+		oc.AddResourceToDelete(resource, result)
+		created = append(created, result)
+	}
+	return created, values, nil
+}
+
+// loadTemplate reads the template from a file if one exists at templateNameOrFile, and
+// otherwise gets it from the API.
+func loadTemplate(oc *CLI, templateNameOrFile string) (*templatev1.Template, error) {
+	if _, err := os.Stat(templateNameOrFile); err == nil {
+		f, err := os.Open(templateNameOrFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		template := &templatev1.Template{}
+		if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(template); err != nil {
+			return nil, fmt.Errorf("unable to decode template %s: %w", templateNameOrFile, err)
+		}
+		return template, nil
+	}
+
+	namespace, name := oc.Namespace(), templateNameOrFile
+	if i := strings.Index(templateNameOrFile, "/"); i >= 0 {
+		namespace, name = templateNameOrFile[:i], templateNameOrFile[i+1:]
+	}
+	return oc.AdminTemplateClient().TemplateV1().Templates(namespace).Get(context.Background(), name, metav1.GetOptions{})
+}
+
+// setTemplateParameters sets the value of the template parameters, failing on parameters the
+// template does not define.
+func setTemplateParameters(template *templatev1.Template, params map[string]string) error {
+	defined := map[string]bool{}
+	for i := range template.Parameters {
+		param := &template.Parameters[i]
+		defined[param.Name] = true
+		if value, ok := params[param.Name]; ok {
+			param.Value = value
+			param.Generate = ""
+		}
+	}
+	var unknown []string
+	for name := range params {
+		if !defined[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("parameters not defined by the template: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
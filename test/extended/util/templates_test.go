@@ -0,0 +1,30 @@
+package util
+
+import (
+	"testing"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+func TestSetTemplateParameters(t *testing.T) {
+	newTemplate := func() *templatev1.Template {
+		return &templatev1.Template{Parameters: []templatev1.Parameter{
+			{Name: "NAME", Value: "default"},
+			{Name: "PASSWORD", Generate: "expression", From: "[a-z]{8}"},
+		}}
+	}
+
+	template := newTemplate()
+	if err := setTemplateParameters(template, map[string]string{"NAME": "frontend", "PASSWORD": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if template.Parameters[0].Value != "frontend" || template.Parameters[1].Value != "secret" || len(template.Parameters[1].Generate) > 0 {
+		t.Errorf("parameters were not set: %#v", template.Parameters)
+	}
+
+	template = newTemplate()
+	err := setTemplateParameters(template, map[string]string{"NAME": "frontend", "REPLICAS": "2", "IMAGE": "busybox"})
+	if err == nil || err.Error() != "parameters not defined by the template: IMAGE, REPLICAS" {
+		t.Errorf("expected an error for the undefined parameters, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/onsi/ginkgo/v2"
 	"k8s.io/klog/v2"
@@ -97,6 +98,20 @@ func InitTest(dryRun bool) error {
 
 var testsStarted bool
 
+// specDeadlineGracePeriod is left to the spec to report failures once a
+// command bound to the spec deadline has been killed.
+const specDeadlineGracePeriod = 30 * time.Second
+
+// specDeadline returns the deadline of the spec context ctx, minus a grace
+// period for reporting. It returns the zero time when ctx has no deadline.
+func specDeadline(ctx context.Context) time.Time {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Time{}
+	}
+	return deadline.Add(-specDeadlineGracePeriod)
+}
+
 // requiresTestStart indicates this code should never be called from within init() or
 // Ginkgo test definition.
 //
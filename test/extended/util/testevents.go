@@ -0,0 +1,83 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	kapierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// testRunnerEventSource is the source of the events recorded by RecordTestEvent.
+const testRunnerEventSource = "test-runner"
+
+// maxTestEventMessageLength bounds the message of the events recorded by RecordTestEvent, the
+// events.k8s.io API rejecting notes longer than 1kB.
+const maxTestEventMessageLength = 1024
+
+// RecordTestEvent records a Normal event with the reason and message on the test namespace, so
+// that the phases of a test can be correlated with the events and logs of the controllers. It is
+// best-effort: failures are logged, and nothing is recorded when there is no test namespace or the
+// user is not allowed to create events. SetupProject and TeardownProject record the Started and
+// Finished events of the test.
+func (c *CLI) RecordTestEvent(reason, message string) {
+	ns := c.Namespace()
+	if len(ns) == 0 {
+		return
+	}
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: testRunnerEventSource + "-"},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       ns,
+		},
+		Reason:              reason,
+		Message:             sanitizeTestEventMessage(message),
+		Type:                corev1.EventTypeNormal,
+		Source:              corev1.EventSource{Component: testRunnerEventSource},
+		ReportingController: testRunnerEventSource,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+	_, err := c.KubeClient().CoreV1().Events(ns).Create(context.Background(), event, metav1.CreateOptions{})
+	switch {
+	case kapierrs.IsForbidden(err):
+		framework.Logf("Not recording test event %s, %s cannot create events in %s", reason, c.Username(), ns)
+	case err != nil:
+		framework.Logf("Unable to record test event %s in %s: %v", reason, ns, err)
+	}
+}
+
+// recordTestBoundaryEvent records the Started or Finished event of the current spec.
+func (c *CLI) recordTestBoundaryEvent(reason string) {
+	report := g.CurrentSpecReport()
+	message := fmt.Sprintf("%s %s", reason, report.FullText())
+	if reason == "Finished" {
+		message = fmt.Sprintf("%s (%s)", message, report.State)
+	}
+	c.RecordTestEvent(reason, message)
+}
+
+// sanitizeTestEventMessage drops the control characters of message, which holds the test name,
+// and truncates it to the length accepted for events.
+func sanitizeTestEventMessage(message string) string {
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, message)
+	if len(message) > maxTestEventMessageLength {
+		message = strings.ToValidUTF8(message[:maxTestEventMessageLength-3], "") + "..."
+	}
+	return message
+}
@@ -0,0 +1,17 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeTestEventMessage(t *testing.T) {
+	if got := sanitizeTestEventMessage("Started [sig-apps]\tDeployment\nshould roll"); got != "Started [sig-apps] Deployment should roll" {
+		t.Errorf("unexpected message %q", got)
+	}
+	long := sanitizeTestEventMessage(strings.Repeat("é", maxTestEventMessageLength))
+	if len(long) > maxTestEventMessageLength || !utf8.ValidString(long) || !strings.HasSuffix(long, "...") {
+		t.Errorf("unexpected truncated message of length %d: %q", len(long), long)
+	}
+}
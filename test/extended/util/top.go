@@ -0,0 +1,220 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// NodeMetrics is the resource usage of a node.
+type NodeMetrics struct {
+	Name string
+	// Timestamp is when the usage was measured, it is zero for usage parsed from oc adm top
+	Timestamp time.Time
+	CPU       resource.Quantity
+	Memory    resource.Quantity
+}
+
+// PodMetrics is the resource usage of a pod.
+type PodMetrics struct {
+	Namespace string
+	Name      string
+	// Timestamp is when the usage was measured, it is zero for usage parsed from oc adm top
+	Timestamp time.Time
+	CPU       resource.Quantity
+	Memory    resource.Quantity
+	// Containers is only set for usage read from the metrics API
+	Containers []ContainerMetrics
+}
+
+// ContainerMetrics is the resource usage of a container.
+type ContainerMetrics struct {
+	Name   string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// metricsList is the subset of the metrics.k8s.io NodeMetricsList and PodMetricsList read here,
+// the metrics clientset not being vendored.
+type metricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Timestamp  time.Time           `json:"timestamp"`
+		Usage      corev1.ResourceList `json:"usage"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// TopNodes returns the resource usage of the nodes from the metrics.k8s.io API, falling back to
+// parsing `oc adm top nodes` when the API cannot be read.
+func TopNodes(oc *CLI) ([]NodeMetrics, error) {
+	list, err := getMetricsList(oc, "/apis/metrics.k8s.io/v1beta1/nodes")
+	if err != nil {
+		e2e.Logf("Unable to read the node metrics API, falling back to oc adm top: %v", err)
+		out, err := oc.AsAdmin().WithoutNamespace().Run("adm", "top").Args("nodes").Output()
+		if err != nil {
+			return nil, err
+		}
+		return parseTopNodes(out)
+	}
+	var nodes []NodeMetrics
+	for _, item := range list.Items {
+		nodes = append(nodes, NodeMetrics{
+			Name:      item.Metadata.Name,
+			Timestamp: item.Timestamp,
+			CPU:       item.Usage[corev1.ResourceCPU],
+			Memory:    item.Usage[corev1.ResourceMemory],
+		})
+	}
+	return nodes, nil
+}
+
+// TopPods returns the resource usage of the pods in the namespace from the metrics.k8s.io API,
+// falling back to parsing `oc adm top pods` when the API cannot be read. Pods that started
+// recently may be missing until metrics-server scrapes them, see WaitForPodMetrics.
+func TopPods(oc *CLI, ns string) ([]PodMetrics, error) {
+	list, err := getMetricsList(oc, fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods", ns))
+	if err != nil {
+		e2e.Logf("Unable to read the pod metrics API, falling back to oc adm top: %v", err)
+		out, err := oc.AsAdmin().WithoutNamespace().Run("adm", "top").Args("pods", "-n", ns).Output()
+		if err != nil {
+			return nil, err
+		}
+		return parseTopPods(ns, out)
+	}
+	var pods []PodMetrics
+	for _, item := range list.Items {
+		pod := PodMetrics{
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Timestamp: item.Timestamp,
+		}
+		for _, container := range item.Containers {
+			cpu, memory := container.Usage[corev1.ResourceCPU], container.Usage[corev1.ResourceMemory]
+			pod.Containers = append(pod.Containers, ContainerMetrics{Name: container.Name, CPU: cpu, Memory: memory})
+			pod.CPU.Add(cpu)
+			pod.Memory.Add(memory)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// WaitForPodMetrics waits until metrics-server reports the usage of the pod and returns it.
+func WaitForPodMetrics(oc *CLI, ns, name string, timeout time.Duration) (*PodMetrics, error) {
+	var found *PodMetrics
+	err := pollUntilTimeout(context.Background(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		pods, err := TopPods(oc, ns)
+		if err != nil {
+			e2e.Logf("Unable to get the pod metrics of namespace %s: %v", ns, err)
+			return false, nil
+		}
+		for i := range pods {
+			if pods[i].Name == name {
+				found = &pods[i]
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for the metrics of pod %s/%s: %w", ns, name, err)
+	}
+	return found, nil
+}
+
+func getMetricsList(oc *CLI, path string) (*metricsList, error) {
+	data, err := oc.AdminKubeClient().CoreV1().RESTClient().Get().AbsPath(path).DoRaw(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	list := &metricsList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// parseTopNodes parses the output of `oc adm top nodes`.
+func parseTopNodes(out string) ([]NodeMetrics, error) {
+	rows, err := parseTopOutput(out)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []NodeMetrics
+	for _, row := range rows {
+		nodes = append(nodes, NodeMetrics{Name: row.name, CPU: row.cpu, Memory: row.memory})
+	}
+	return nodes, nil
+}
+
+// parseTopPods parses the output of `oc adm top pods` for the namespace.
+func parseTopPods(ns, out string) ([]PodMetrics, error) {
+	rows, err := parseTopOutput(out)
+	if err != nil {
+		return nil, err
+	}
+	var pods []PodMetrics
+	for _, row := range rows {
+		pods = append(pods, PodMetrics{Namespace: ns, Name: row.name, CPU: row.cpu, Memory: row.memory})
+	}
+	return pods, nil
+}
+
+type topRow struct {
+	name   string
+	cpu    resource.Quantity
+	memory resource.Quantity
+}
+
+// parseTopOutput reads the NAME, CPU(cores) and MEMORY(bytes) columns of oc adm top output. The
+// columns are located through the header so that added columns do not break parsing.
+func parseTopOutput(out string) ([]topRow, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) == 0 || len(strings.TrimSpace(lines[0])) == 0 {
+		return nil, fmt.Errorf("no output from oc adm top")
+	}
+	nameColumn, cpuColumn, memoryColumn := -1, -1, -1
+	for i, header := range strings.Fields(lines[0]) {
+		switch header {
+		case "NAME":
+			nameColumn = i
+		case "CPU(cores)":
+			cpuColumn = i
+		case "MEMORY(bytes)":
+			memoryColumn = i
+		}
+	}
+	if nameColumn < 0 || cpuColumn < 0 || memoryColumn < 0 {
+		return nil, fmt.Errorf("unexpected oc adm top header %q", lines[0])
+	}
+	var rows []topRow
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) <= max(nameColumn, cpuColumn, memoryColumn) {
+			return nil, fmt.Errorf("unexpected oc adm top line %q", line)
+		}
+		cpu, err := resource.ParseQuantity(fields[cpuColumn])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected CPU usage in line %q: %w", line, err)
+		}
+		memory, err := resource.ParseQuantity(fields[memoryColumn])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected memory usage in line %q: %w", line, err)
+		}
+		rows = append(rows, topRow{name: fields[nameColumn], cpu: cpu, memory: memory})
+	}
+	return rows, nil
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseTopOutput(t *testing.T) {
+	nodes, err := parseTopNodes(`NAME       CPU(cores)   CPU(%)   MEMORY(bytes)   MEMORY(%)
+master-0   1203m        16%      9876Mi          66%
+worker-0   250m         3%       2048Mi          13%
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "master-0" || nodes[1].Name != "worker-0" {
+		t.Fatalf("unexpected nodes %v", nodes)
+	}
+	if !nodes[0].CPU.Equal(resource.MustParse("1203m")) || !nodes[1].Memory.Equal(resource.MustParse("2048Mi")) {
+		t.Errorf("unexpected usage %s/%s and %s/%s", nodes[0].CPU.String(), nodes[0].Memory.String(), nodes[1].CPU.String(), nodes[1].Memory.String())
+	}
+
+	// columns are found through the header, not by position
+	pods, err := parseTopPods("e2e-test", `NAME    MEMORY(bytes)   CPU(cores)
+web-1   12Mi            3m
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Namespace != "e2e-test" || !pods[0].CPU.Equal(resource.MustParse("3m")) || !pods[0].Memory.Equal(resource.MustParse("12Mi")) {
+		t.Errorf("unexpected pods %v", pods)
+	}
+
+	for _, out := range []string{"", "NAME STATUS\nweb-1 Running", "NAME CPU(cores) MEMORY(bytes)\nweb-1 3m"} {
+		if _, err := parseTopOutput(out); err == nil {
+			t.Errorf("expected an error parsing %q", out)
+		}
+	}
+}
+
+func TestDecodeMetricsList(t *testing.T) {
+	data := []byte(`{"kind":"PodMetricsList","items":[{"metadata":{"name":"web-1","namespace":"e2e-test"},"timestamp":"2024-01-02T03:04:05Z","window":"20s","containers":[{"name":"web","usage":{"cpu":"2m","memory":"10Mi"}},{"name":"proxy","usage":{"cpu":"1m","memory":"2Mi"}}]}]}`)
+	list := &metricsList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Metadata.Namespace != "e2e-test" || len(list.Items[0].Containers) != 2 {
+		t.Fatalf("unexpected list %+v", list)
+	}
+	cpu := list.Items[0].Containers[0].Usage[corev1.ResourceCPU]
+	if !cpu.Equal(resource.MustParse("2m")) {
+		t.Errorf("unexpected CPU usage %s", cpu.String())
+	}
+}
@@ -2,16 +2,24 @@ package util
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/yaml"
 )
 
 func WaitForCMState(ctx context.Context, client corev1client.CoreV1Interface, namespace string, name string, condition func(cm *corev1.ConfigMap) (bool, error)) (*corev1.ConfigMap, error) {
@@ -39,3 +47,201 @@ func WaitForCMState(ctx context.Context, client corev1client.CoreV1Interface, na
 	}
 	return event.Object.(*corev1.ConfigMap), nil
 }
+
+// WaitForResourceCondition waits for check to be true for the named object of the resource, which
+// may be cluster scoped when ns is empty, and returns the object. On timeout the returned error
+// includes the conditions of the last observed object as YAML.
+func WaitForResourceCondition(oc *CLI, gvr schema.GroupVersionResource, ns, name string, check func(*unstructured.Unstructured) (bool, error), timeout time.Duration) (*unstructured.Unstructured, error) {
+	client := oc.AdminDynamicClient().Resource(gvr).Namespace(ns)
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return client.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return client.Watch(ctx, options)
+		},
+	}
+
+	var lastObserved *unstructured.Unstructured
+	_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, nil, func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			lastObserved = event.Object.(*unstructured.Unstructured)
+			return check(lastObserved)
+		}
+		return false, nil
+	})
+	if err == nil {
+		return lastObserved, nil
+	}
+	if lastObserved == nil {
+		return nil, fmt.Errorf("waiting for %s %s/%s: %w (object not observed)", gvr.Resource, ns, name, err)
+	}
+	conditions, _, _ := unstructured.NestedFieldNoCopy(lastObserved.Object, "status", "conditions")
+	conditionsYAML, yamlErr := yaml.Marshal(conditions)
+	if yamlErr != nil {
+		conditionsYAML = []byte(fmt.Sprintf("<unable to serialize conditions: %v>", yamlErr))
+	}
+	return lastObserved, fmt.Errorf("waiting for %s %s/%s: %w, last observed conditions:\n%s", gvr.Resource, ns, name, err, conditionsYAML)
+}
+
+// UnstructuredConditionIsTrue returns a check for WaitForResourceCondition that is true when the
+// condition of type condType has status True. Both the usual list of conditions with type and
+// status fields and conditions keyed by type in a map are understood.
+func UnstructuredConditionIsTrue(condType string) func(*unstructured.Unstructured) (bool, error) {
+	return func(obj *unstructured.Unstructured) (bool, error) {
+		condition, err := unstructuredCondition(obj, condType)
+		if err != nil || condition == nil {
+			return false, err
+		}
+		return isTrueStatus(condition["status"]), nil
+	}
+}
+
+// WaitForStatusCondition waits for the status condition of type conditionType of the named object
+// of the resource, which may be cluster scoped when namespace is empty, to have expectedStatus,
+// compared case insensitively. Conditions are read as described for UnstructuredConditionIsTrue.
+// On timeout the returned error includes the last observed condition with its reason and message.
+func (c *CLI) WaitForStatusCondition(gvr schema.GroupVersionResource, namespace, name, conditionType, expectedStatus string, timeout time.Duration) error {
+	var last map[string]interface{}
+	_, err := WaitForResourceCondition(c, gvr, namespace, name, func(obj *unstructured.Unstructured) (bool, error) {
+		condition, err := unstructuredCondition(obj, conditionType)
+		if err != nil {
+			return false, err
+		}
+		last = condition
+		return condition != nil && strings.EqualFold(conditionStatusString(condition["status"]), expectedStatus), nil
+	}, timeout)
+	if err == nil || last == nil {
+		return err
+	}
+	return fmt.Errorf("condition %s of %s %s/%s is %s instead of %s (reason %v: %v): %w",
+		conditionType, gvr.Resource, namespace, name, conditionStatusString(last["status"]), expectedStatus, last["reason"], last["message"], err)
+}
+
+// unstructuredCondition returns the condition of type condType of obj, or nil if it has none.
+// A condition keyed by type whose value is its status is returned as a condition with only a
+// status field.
+func unstructuredCondition(obj *unstructured.Unstructured, condType string) (map[string]interface{}, error) {
+	conditions, found, err := unstructured.NestedFieldNoCopy(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil, err
+	}
+	switch conditions := conditions.(type) {
+	case []interface{}:
+		for _, condition := range conditions {
+			condition, ok := condition.(map[string]interface{})
+			if ok && condition["type"] == condType {
+				return condition, nil
+			}
+		}
+	case map[string]interface{}:
+		switch condition := conditions[condType].(type) {
+		case nil:
+		case map[string]interface{}:
+			return condition, nil
+		default:
+			return map[string]interface{}{"status": condition}, nil
+		}
+	}
+	return nil, nil
+}
+
+// conditionStatusString returns the status of a condition as a string, booleans being converted
+// to "True" and "False".
+func conditionStatusString(status interface{}) string {
+	switch status := status.(type) {
+	case string:
+		return status
+	case bool:
+		if status {
+			return "True"
+		}
+		return "False"
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(status)
+}
+
+// isTrueStatus accepts both "True" strings and booleans as condition statuses.
+func isTrueStatus(status interface{}) bool {
+	switch status := status.(type) {
+	case string:
+		return strings.EqualFold(status, "True")
+	case bool:
+		return status
+	}
+	return false
+}
+
+// waitClock drives the polling of the wait helpers. Unit tests replace it with a fake clock to
+// exercise timeouts without sleeping.
+var waitClock clock.WithTickerAndDelayedExecution = clock.RealClock{}
+
+// pollUntilTimeout behaves like wait.PollUntilContextTimeout, with the interval and the timeout
+// measured by waitClock. The context passed to condition is cancelled once the timeout is reached,
+// so that a condition blocked in an API call returns, and is also bounded by a real-time deadline.
+// It returns context.DeadlineExceeded once the timeout is reached, so that wait.Interrupted
+// recognizes it.
+func pollUntilTimeout(ctx context.Context, interval, timeout time.Duration, immediate bool, condition wait.ConditionWithContextFunc) error {
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	defer cancelTimeout()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	deadline := waitClock.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	defer deadline.Stop()
+	ticker := waitClock.NewTicker(interval)
+	defer ticker.Stop()
+
+	if immediate {
+		if done, err := runCondition(ctx, condition); err != nil || done {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C():
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			if done, err := runCondition(ctx, condition); err != nil || done {
+				return err
+			}
+		}
+	}
+}
+
+// poll replaces wait.Poll and wait.PollImmediate so that the polling is measured by waitClock
+// too. As with those, wait.ErrWaitTimeout is returned once the timeout is reached.
+func poll(interval, timeout time.Duration, immediate bool, condition wait.ConditionFunc) error {
+	var conditionErr error
+	err := pollUntilTimeout(context.Background(), interval, timeout, immediate, func(context.Context) (bool, error) {
+		done, err := condition()
+		conditionErr = err
+		return done, err
+	})
+	if err != nil && err != conditionErr {
+		return wait.ErrWaitTimeout
+	}
+	return err
+}
+
+// runCondition runs condition, reporting the reason ctx was cancelled when the condition fails
+// with the error of its cancelled context.
+func runCondition(ctx context.Context, condition wait.ConditionWithContextFunc) (bool, error) {
+	done, err := condition(ctx)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return false, context.Cause(ctx)
+	}
+	return done, err
+}
@@ -0,0 +1,233 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	kubeauthorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestUnstructuredConditionIsTrue(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions interface{}
+		want       bool
+	}{
+		{
+			name: "no conditions",
+		},
+		{
+			name:       "list with true condition",
+			conditions: []interface{}{map[string]interface{}{"type": "Progressing", "status": "False"}, map[string]interface{}{"type": "Ready", "status": "True"}},
+			want:       true,
+		},
+		{
+			name:       "list with false condition",
+			conditions: []interface{}{map[string]interface{}{"type": "Ready", "status": "False"}},
+		},
+		{
+			name:       "list without the condition",
+			conditions: []interface{}{map[string]interface{}{"type": "Progressing", "status": "True"}},
+		},
+		{
+			name:       "map of condition objects",
+			conditions: map[string]interface{}{"Ready": map[string]interface{}{"status": "True"}},
+			want:       true,
+		},
+		{
+			name:       "map of boolean statuses",
+			conditions: map[string]interface{}{"Ready": true},
+			want:       true,
+		},
+		{
+			name:       "map with false status",
+			conditions: map[string]interface{}{"Ready": map[string]interface{}{"status": "False"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.conditions != nil {
+				obj.Object["status"] = map[string]interface{}{"conditions": tt.conditions}
+			}
+			got, err := UnstructuredConditionIsTrue("Ready")(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// withFakeWaitClock swaps waitClock for a fake one for the duration of the test.
+func withFakeWaitClock(t *testing.T) *clocktesting.FakeClock {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	previous := waitClock
+	waitClock = fakeClock
+	t.Cleanup(func() { waitClock = previous })
+	return fakeClock
+}
+
+// stepUntilDone advances fakeClock by step whenever the poll is waiting on it, until fn returns.
+func stepUntilDone(fakeClock *clocktesting.FakeClock, step time.Duration, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	for {
+		select {
+		case err := <-done:
+			return err
+		default:
+		}
+		if fakeClock.HasWaiters() {
+			fakeClock.Step(step)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// deniedAccessClient answers every SelfSubjectAccessReview with a denial.
+func deniedAccessClient() *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &kubeauthorizationv1.SelfSubjectAccessReview{}, nil
+	})
+	return client
+}
+
+func TestWaitForAccessTimesOut(t *testing.T) {
+	fakeClock := withFakeWaitClock(t)
+	start := fakeClock.Now()
+
+	client := deniedAccessClient()
+	err := stepUntilDone(fakeClock, time.Second, func() error {
+		return WaitForAccess(client, true, &kubeauthorizationv1.SelfSubjectAccessReview{})
+	})
+	if !wait.Interrupted(err) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := fakeClock.Since(start); elapsed < time.Minute {
+		t.Errorf("expected to give up after a minute, gave up after %s", elapsed)
+	}
+}
+
+func TestWaitForAccessSucceeds(t *testing.T) {
+	fakeClock := withFakeWaitClock(t)
+
+	client := deniedAccessClient()
+	err := stepUntilDone(fakeClock, time.Second, func() error {
+		return WaitForAccess(client, false, &kubeauthorizationv1.SelfSubjectAccessReview{})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPollUntilTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		immediate bool
+		doneAfter int
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "immediate success",
+			immediate: true,
+			doneAfter: 1,
+			wantCalls: 1,
+		},
+		{
+			name:      "success after ticks",
+			doneAfter: 3,
+			wantCalls: 3,
+		},
+		{
+			name:      "timeout",
+			immediate: true,
+			doneAfter: 100,
+			wantCalls: 10,
+			wantErr:   context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := withFakeWaitClock(t)
+			calls := 0
+			err := stepUntilDone(fakeClock, time.Second, func() error {
+				return pollUntilTimeout(context.Background(), time.Second, 10*time.Second, tt.immediate, func(context.Context) (bool, error) {
+					calls++
+					return calls >= tt.doneAfter, nil
+				})
+			})
+			if err != tt.wantErr {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if calls < tt.wantCalls-1 || calls > tt.wantCalls {
+				t.Errorf("condition called %d times, want about %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPollUntilTimeoutCancelsBlockedCondition(t *testing.T) {
+	fakeClock := withFakeWaitClock(t)
+	start := fakeClock.Now()
+
+	err := stepUntilDone(fakeClock, time.Second, func() error {
+		return pollUntilTimeout(context.Background(), time.Second, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+			<-ctx.Done()
+			return false, ctx.Err()
+		})
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := fakeClock.Since(start); elapsed < 10*time.Second {
+		t.Errorf("expected to give up after the timeout, gave up after %s", elapsed)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	fakeClock := withFakeWaitClock(t)
+	conditionErr := errors.New("condition failed")
+
+	err := stepUntilDone(fakeClock, time.Second, func() error {
+		return poll(time.Second, 10*time.Second, false, func() (bool, error) { return false, nil })
+	})
+	if err != wait.ErrWaitTimeout {
+		t.Errorf("got error %v, want %v", err, wait.ErrWaitTimeout)
+	}
+
+	err = stepUntilDone(fakeClock, time.Second, func() error {
+		return poll(time.Second, 10*time.Second, true, func() (bool, error) { return false, conditionErr })
+	})
+	if err != conditionErr {
+		t.Errorf("got error %v, want %v", err, conditionErr)
+	}
+}
+
+func TestWaitForSelfSARTimesOut(t *testing.T) {
+	fakeClock := withFakeWaitClock(t)
+	start := fakeClock.Now()
+
+	err := stepUntilDone(fakeClock, time.Second, func() error {
+		return WaitForSelfSAR(time.Second, time.Minute, deniedAccessClient(), kubeauthorizationv1.SelfSubjectAccessReviewSpec{})
+	})
+	if err == nil || !strings.Contains(err.Error(), wait.ErrWaitTimeout.Error()) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := fakeClock.Since(start); elapsed < time.Minute {
+		t.Errorf("expected to give up after a minute, gave up after %s", elapsed)
+	}
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// WatchResource watches the resource with the dynamic client and returns the events and a func
+// that stops the watch, which is also called at the end of the test. Like `oc get -w`, the objects
+// that exist when the watch starts are sent first as Added events. The watch is resumed from the
+// last observed resourceVersion when it is closed by the server, and the channel is closed once the
+// watch is stopped, ctx is done, or the resourceVersion expired.
+func WatchResource(ctx context.Context, oc *CLI, gvr schema.GroupVersionResource, ns string, opts metav1.ListOptions) (<-chan watch.Event, func(), error) {
+	client := oc.AdminDynamicClient().Resource(gvr).Namespace(ns)
+	list, err := client.List(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	watcher, err := watchtools.NewRetryWatcher(list.GetResourceVersion(), &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			watchOpts := *opts.DeepCopy()
+			watchOpts.ResourceVersion = options.ResourceVersion
+			watchOpts.AllowWatchBookmarks = options.AllowWatchBookmarks
+			return client.Watch(ctx, watchOpts)
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan watch.Event)
+	stopped := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(stopped)
+			watcher.Stop()
+		})
+	}
+	send := func(event watch.Event) bool {
+		select {
+		case events <- event:
+			return true
+		case <-stopped:
+		case <-ctx.Done():
+		}
+		return false
+	}
+	done := oc.backgroundWork.trackGoroutine(fmt.Sprintf("watch of %s in %q", gvr.String(), ns), stop)
+	go func() {
+		defer done()
+		defer close(events)
+		defer stop()
+		for i := range list.Items {
+			if !send(watch.Event{Type: watch.Added, Object: &list.Items[i]}) {
+				return
+			}
+		}
+		for event := range watcher.ResultChan() {
+			if !send(event) {
+				return
+			}
+		}
+	}()
+	g.DeferCleanup(stop)
+	return events, stop, nil
+}
+
+// CollectEventsUntil returns the events received on ch, in order, up to and including the first
+// one matching predicate. An error is returned along with the events received so far if ch is
+// closed or timeout elapses first.
+func CollectEventsUntil(ch <-chan watch.Event, predicate func(watch.Event) bool, timeout time.Duration) ([]watch.Event, error) {
+	timer := waitClock.NewTimer(timeout)
+	defer timer.Stop()
+	var events []watch.Event
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events, fmt.Errorf("watch closed after %d events", len(events))
+			}
+			events = append(events, event)
+			if predicate(event) {
+				return events, nil
+			}
+		case <-timer.C():
+			return events, fmt.Errorf("no matching event within %s after %d events: %w", timeout, len(events), context.DeadlineExceeded)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestCollectEventsUntil(t *testing.T) {
+	podEvent := func(eventType watch.EventType, phase corev1.PodPhase) watch.Event {
+		return watch.Event{Type: eventType, Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}, Status: corev1.PodStatus{Phase: phase}}}
+	}
+	succeeded := func(event watch.Event) bool {
+		pod, ok := event.Object.(*corev1.Pod)
+		return ok && pod.Status.Phase == corev1.PodSucceeded
+	}
+
+	ch := make(chan watch.Event, 4)
+	ch <- podEvent(watch.Added, corev1.PodPending)
+	ch <- podEvent(watch.Modified, corev1.PodRunning)
+	ch <- podEvent(watch.Modified, corev1.PodSucceeded)
+	ch <- podEvent(watch.Deleted, corev1.PodSucceeded)
+	events, err := CollectEventsUntil(ch, succeeded, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var phases []corev1.PodPhase
+	for _, event := range events {
+		phases = append(phases, event.Object.(*corev1.Pod).Status.Phase)
+	}
+	if len(phases) != 3 || phases[0] != corev1.PodPending || phases[1] != corev1.PodRunning || phases[2] != corev1.PodSucceeded {
+		t.Errorf("unexpected phases %v", phases)
+	}
+
+	closed := make(chan watch.Event, 1)
+	closed <- podEvent(watch.Added, corev1.PodRunning)
+	close(closed)
+	if events, err := CollectEventsUntil(closed, succeeded, time.Minute); err == nil || len(events) != 1 {
+		t.Errorf("expected an error with 1 event for a closed watch, got %d events and %v", len(events), err)
+	}
+
+	fakeClock := withFakeWaitClock(t)
+	err = stepUntilDone(fakeClock, time.Minute, func() error {
+		_, err := CollectEventsUntil(make(chan watch.Event), succeeded, time.Minute)
+		return err
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
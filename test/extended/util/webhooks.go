@@ -0,0 +1,125 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// WaitForAPIServiceAvailable waits for the APIService to report the Available condition.
+// On timeout the returned error includes the last observed condition.
+func (c *CLI) WaitForAPIServiceAvailable(name string, timeout time.Duration) error {
+	client, err := apiregistrationclient.NewForConfig(c.AdminConfig())
+	if err != nil {
+		return err
+	}
+
+	lastStatus := "<not found>"
+	err = pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		apiService, err := client.ApiregistrationV1().APIServices().Get(ctx, name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range apiService.Status.Conditions {
+			if condition.Type == apiregistrationv1.Available {
+				lastStatus = fmt.Sprintf("%s=%s (%s): %s", condition.Type, condition.Status, condition.Reason, condition.Message)
+				return condition.Status == apiregistrationv1.ConditionTrue, nil
+			}
+		}
+		lastStatus = "<no Available condition>"
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for APIService %s to be available: %w (last status: %s)", name, err, lastStatus)
+	}
+	e2e.Logf("APIService %s is available", name)
+	return nil
+}
+
+// WaitForWebhookReady waits until every service backing the webhooks of the named
+// ValidatingWebhookConfiguration or MutatingWebhookConfiguration has ready endpoints.
+// On timeout the returned error lists the services that are not ready.
+func (c *CLI) WaitForWebhookReady(name string, timeout time.Duration) error {
+	var notReady []string
+	err := pollUntilTimeout(context.Background(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		services, err := c.webhookServices(ctx, name)
+		if err != nil {
+			notReady = []string{err.Error()}
+			return false, nil
+		}
+		notReady = nil
+		for _, svc := range services {
+			ready, err := c.serviceHasReadyEndpoints(ctx, svc.Namespace, svc.Name)
+			if err != nil {
+				return false, err
+			}
+			if !ready {
+				notReady = append(notReady, svc.Namespace+"/"+svc.Name)
+			}
+		}
+		return len(notReady) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for webhook configuration %s to be ready: %w (not ready: %s)", name, err, strings.Join(notReady, ", "))
+	}
+	e2e.Logf("Webhook configuration %s is ready", name)
+	return nil
+}
+
+// webhookServices returns the services referenced by the named webhook configuration.
+func (c *CLI) webhookServices(ctx context.Context, name string) ([]*admissionregistrationv1.ServiceReference, error) {
+	var services []*admissionregistrationv1.ServiceReference
+	client := c.AdminKubeClient().AdmissionregistrationV1()
+
+	validating, err := client.ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		for _, webhook := range validating.Webhooks {
+			if webhook.ClientConfig.Service != nil {
+				services = append(services, webhook.ClientConfig.Service)
+			}
+		}
+		return services, nil
+	case !kerrors.IsNotFound(err):
+		return nil, err
+	}
+
+	mutating, err := client.MutatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("no validating or mutating webhook configuration %s: %w", name, err)
+	}
+	for _, webhook := range mutating.Webhooks {
+		if webhook.ClientConfig.Service != nil {
+			services = append(services, webhook.ClientConfig.Service)
+		}
+	}
+	return services, nil
+}
+
+// serviceHasReadyEndpoints returns true if the service has at least one ready endpoint address.
+func (c *CLI) serviceHasReadyEndpoints(ctx context.Context, namespace, name string) (bool, error) {
+	ep, err := c.AdminKubeClient().CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, subset := range ep.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}